@@ -27,7 +27,7 @@ func New() *cli.App {
 			{
 				Name:    "add",
 				Aliases: []string{"a"},
-				Usage:   "Adds a new expense",
+				Usage:   "Adds a new expense (or income)",
 				Action:  HandleAdd,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
@@ -36,11 +36,30 @@ func New() *cli.App {
 						Usage:       "The task's description",
 						DefaultText: "an expense",
 					},
-					&cli.Float64Flag{
+					&cli.StringFlag{
 						Name:    "amount",
 						Aliases: []string{"a"},
 						Usage:   "The price of the expense",
 					},
+					&cli.StringFlag{
+						Name:    "category",
+						Aliases: []string{"c"},
+						Usage:   "The expense's category",
+					},
+					&cli.StringFlag{
+						Name:  "date",
+						Usage: "When the expense was made, like 2026-09-30, yesterday or friday (defaults to today)",
+					},
+					&cli.StringFlag{
+						Name:  "currency",
+						Usage: "The expense's currency, like EUR (defaults to the base currency)",
+					},
+					&cli.StringFlag{
+						Name:        "type",
+						Aliases:     []string{"t"},
+						Usage:       "Whether it's an expense or income",
+						DefaultText: "expense",
+					},
 				},
 			},
 			{
@@ -58,18 +77,56 @@ func New() *cli.App {
 						Aliases: []string{"d"},
 						Usage:   "The task's description",
 					},
-					&cli.Float64Flag{
+					&cli.StringFlag{
 						Name:    "amount",
 						Aliases: []string{"a"},
 						Usage:   "The price of the expense",
 					},
+					&cli.StringFlag{
+						Name:    "category",
+						Aliases: []string{"c"},
+						Usage:   "The expense's category (empty to remove it)",
+					},
+					&cli.StringFlag{
+						Name:  "date",
+						Usage: "When the expense was made, like 2026-09-30, yesterday or friday",
+					},
+					&cli.StringFlag{
+						Name:  "currency",
+						Usage: "The expense's currency, like EUR",
+					},
+					&cli.StringFlag{
+						Name:    "type",
+						Aliases: []string{"t"},
+						Usage:   "Whether it's an expense or income",
+					},
 				},
 			},
 			{
 				Name:    "list",
 				Aliases: []string{"l"},
-				Usage:   "Lists all expenses",
+				Usage:   "Lists all expenses, oldest first",
 				Action:  HandleList,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "category",
+						Aliases: []string{"c"},
+						Usage:   "Only lists expenses in a category (\"uncategorized\" for the ones without)",
+					},
+					&cli.StringFlag{
+						Name:  "from",
+						Usage: "Only lists expenses from a date on, like 2026-09-01",
+					},
+					&cli.StringFlag{
+						Name:  "to",
+						Usage: "Only lists expenses up to a date (included)",
+					},
+					&cli.StringFlag{
+						Name:    "type",
+						Aliases: []string{"t"},
+						Usage:   "Only lists expenses or income",
+					},
+				},
 			},
 			{
 				Name:    "summary",
@@ -77,9 +134,43 @@ func New() *cli.App {
 				Usage:   "Prints a summary of all expenses",
 				Action:  HandleSummary,
 				Flags: []cli.Flag{
-					&cli.IntFlag{
+					&cli.StringFlag{
 						Name:  "month",
-						Usage: "Limits summary to a given month",
+						Usage: "Limits summary to a given month, like 2026-09",
+					},
+					&cli.IntFlag{
+						Name:  "year",
+						Usage: "Limits summary to a given year",
+					},
+					&cli.StringFlag{
+						Name:  "from",
+						Usage: "Limits summary to expenses from a date on, like 2026-09-01",
+					},
+					&cli.StringFlag{
+						Name:  "to",
+						Usage: "Limits summary to expenses up to a date (included)",
+					},
+					&cli.StringFlag{
+						Name:  "by",
+						Usage: "Groups the summary (by \"category\", or by \"month\" for income, expenses and net)",
+					},
+				},
+			},
+			{
+				Name:    "forecast",
+				Aliases: []string{"f"},
+				Usage:   "Projects spending by the end of the month, and compares it to the limit",
+				Action:  HandleForecast,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "month",
+						Usage:       "Month to forecast, like 2026-09",
+						DefaultText: "this month",
+					},
+					&cli.StringFlag{
+						Name:    "category",
+						Aliases: []string{"c"},
+						Usage:   "Only forecasts a category, against its limit",
 					},
 				},
 			},
@@ -101,16 +192,230 @@ func New() *cli.App {
 				Usage:   "Sets a monthly expense limit",
 				Action:  HandleSetLimit,
 				Flags: []cli.Flag{
-					&cli.IntFlag{
+					&cli.StringFlag{
 						Name:  "month",
-						Usage: "Month to which the limit applies. If not set, limit applies to all months",
+						Usage: "Month to which the limit applies, like 2026-09. If not set, limit applies to all months without their own",
 					},
-					&cli.Float64Flag{
+					&cli.StringFlag{
+						Name:    "category",
+						Aliases: []string{"c"},
+						Usage:   "Category to which the limit applies. If not set, limit applies to all expenses",
+					},
+					&cli.StringFlag{
 						Name:  "amount",
 						Usage: "The monthly limit",
 					},
 				},
 			},
+			{
+				Name:      "import",
+				Aliases:   []string{"i"},
+				Usage:     "Imports expenses from a CSV file (like the ones save writes) or a bank statement",
+				ArgsUsage: "<file>",
+				Action:    HandleImport,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "format",
+						Aliases:     []string{"f"},
+						Usage:       "What the file is: csv, ofx, qfx or qif",
+						DefaultText: "guessed from the file's extension",
+					},
+					&cli.StringFlag{
+						Name:  "date-column",
+						Usage: "Name or number of the date column",
+					},
+					&cli.StringFlag{
+						Name:  "description-column",
+						Usage: "Name or number of the description column",
+					},
+					&cli.StringFlag{
+						Name:  "amount-column",
+						Usage: "Name or number of the amount column",
+					},
+					&cli.StringFlag{
+						Name:  "category-column",
+						Usage: "Name or number of the category column",
+					},
+					&cli.StringFlag{
+						Name:  "currency-column",
+						Usage: "Name or number of the currency column",
+					},
+					&cli.StringFlag{
+						Name:        "date-format",
+						Usage:       "How dates are written, as a Go layout (like 02/01/2006)",
+						DefaultText: DATE_FORMAT,
+					},
+					&cli.BoolFlag{
+						Name:  "decimal-comma",
+						Usage: "Amounts use commas for decimals, like 1.234,56",
+					},
+					&cli.BoolFlag{
+						Name:  "negate",
+						Usage: "Amounts are negative for money going out, like in most bank exports (in CSV files)",
+					},
+					&cli.StringFlag{
+						Name:        "separator",
+						Usage:       "What separates columns (\\t for tabs)",
+						DefaultText: ",",
+					},
+					&cli.BoolFlag{
+						Name:  "no-header",
+						Usage: "The file has no header, so columns must be given by number",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-credits",
+						Usage: "Skips money coming in, instead of taking it as refunds (in bank statements)",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only shows what would be imported",
+					},
+				},
+			},
+			{
+				Name:  "rule",
+				Usage: "Manages rules that put imported expenses in categories, by their descriptions",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "add",
+						Usage:  "Adds a rule",
+						Action: HandleRuleAdd,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "match",
+								Aliases: []string{"m"},
+								Usage:   "Text the description must have, like \"uber\"",
+							},
+							&cli.StringFlag{
+								Name:    "category",
+								Aliases: []string{"c"},
+								Usage:   "Category to put matching expenses in",
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Usage:  "Lists the rules, in the order they're tried",
+						Action: HandleRuleList,
+					},
+					{
+						Name:      "delete",
+						Usage:     "Deletes a rule",
+						ArgsUsage: "<number>",
+						Action:    HandleRuleDelete,
+					},
+				},
+			},
+			{
+				Name:    "recurring",
+				Aliases: []string{"r"},
+				Usage:   "Manages expenses (and income) that happen on a schedule, added as they come due",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "add",
+						Usage:  "Adds a recurring entry",
+						Action: HandleRecurringAdd,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "description",
+								Aliases: []string{"d"},
+								Usage:   "What it is, like Netflix",
+							},
+							&cli.StringFlag{
+								Name:    "amount",
+								Aliases: []string{"a"},
+								Usage:   "How much it costs each time",
+							},
+							&cli.StringFlag{
+								Name:  "every",
+								Usage: "How often it happens: daily, weekly, monthly or yearly",
+								Value: EVERY_MONTH,
+							},
+							&cli.StringFlag{
+								Name:  "day",
+								Usage: "Day of the month (monthly) or of the week (weekly) it happens on",
+							},
+							&cli.StringFlag{
+								Name:  "start",
+								Usage: "When it starts, like 2026-09-30 (defaults to today)",
+							},
+							&cli.StringFlag{
+								Name:    "category",
+								Aliases: []string{"c"},
+								Usage:   "Its category",
+							},
+							&cli.StringFlag{
+								Name:  "currency",
+								Usage: "Its currency, like EUR (defaults to the base currency)",
+							},
+							&cli.StringFlag{
+								Name:        "type",
+								Aliases:     []string{"t"},
+								Usage:       "Whether it's an expense or income",
+								DefaultText: "expense",
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Usage:  "Lists the recurring entries",
+						Action: HandleRecurringList,
+					},
+					{
+						Name:      "pause",
+						Usage:     "Stops adding a recurring entry, until it's resumed",
+						ArgsUsage: "<id>",
+						Action:    HandleRecurringPause,
+					},
+					{
+						Name:      "resume",
+						Usage:     "Starts adding a paused recurring entry again",
+						ArgsUsage: "<id>",
+						Action:    HandleRecurringResume,
+					},
+					{
+						Name:      "delete",
+						Usage:     "Deletes a recurring entry (keeping what was already added)",
+						ArgsUsage: "<id>",
+						Action:    HandleRecurringDelete,
+					},
+					{
+						Name:   "forecast",
+						Usage:  "Lists the charges coming up",
+						Action: HandleRecurringForecast,
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "days",
+								Usage: "How many days ahead to look",
+								Value: 30,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Gets and sets settings (base-currency, rates-file and pace-warning)",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "get",
+						Usage:     "Prints a setting",
+						ArgsUsage: "<key>",
+						Action:    HandleConfigGet,
+					},
+					{
+						Name:      "set",
+						Usage:     "Changes a setting",
+						ArgsUsage: "<key> <value>",
+						Action:    HandleConfigSet,
+					},
+					{
+						Name:   "list",
+						Usage:  "Prints all settings",
+						Action: HandleConfigList,
+					},
+				},
+			},
 			{
 				Name:    "save",
 				Aliases: []string{"sv"},
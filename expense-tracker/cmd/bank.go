@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// What money coming into an account is
+const (
+	CREDIT_REFUND = iota
+	CREDIT_INCOME
+	CREDIT_TRANSFER
+)
+
+// Kinds of OFX transactions that put money in, other than refunds
+var ofxIncomeTypes []string = []string{"INT", "DIV", "DEP", "DIRECTDEP"}
+var ofxTransferTypes []string = []string{"XFER", "PAYMENT"}
+
+// Turns a bank transaction into an entry. Bank statements have money going
+// out as negative amounts, which become expenses, and money coming in as
+// positive ones, which become refunds (negative expenses) or income
+func bankTransaction(line int, date time.Time, description string, amount Money, credit int, options importOptions) importedExpense {
+	if description == "" {
+		description = "an expense"
+	}
+
+	expense := importedExpense{
+		Line:        line,
+		Date:        date,
+		Description: description,
+		Amount:      Money{Units: -amount.Units, Currency: amount.Currency},
+	}
+
+	switch {
+	case amount.IsZero():
+		expense.Skip = "nothing was paid"
+	case amount.Units > 0 && options.SkipCredits:
+		expense.Skip = "money came in"
+	case amount.Units > 0 && credit == CREDIT_TRANSFER:
+		expense.Skip = "it's a transfer between accounts"
+	case amount.Units > 0 && credit == CREDIT_INCOME:
+		expense.Kind = KIND_INCOME
+		expense.Amount = amount
+	}
+
+	return expense
+}
+
+// Reads the transactions in an OFX (or QFX) bank statement, of either the old
+// SGML kind (which doesn't close its tags) or the newer XML one
+func readOFX(r io.Reader, options importOptions) ([]importedExpense, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(data)
+	if !strings.Contains(strings.ToUpper(text), "<OFX>") {
+		return nil, errors.New("Not an OFX file!")
+	}
+
+	imported := []importedExpense{}
+
+	account, currency := "", config.GetBaseCurrency()
+	var transaction map[string]string
+	transactionLine := 0
+
+	for pos := strings.IndexByte(text, '<'); pos >= 0; {
+		end := strings.IndexByte(text[pos:], '>')
+		if end < 0 {
+			break
+		}
+
+		tag := strings.ToUpper(strings.TrimSpace(text[pos+1 : pos+end]))
+		rest := text[pos+end+1:]
+
+		next := strings.IndexByte(rest, '<')
+		value := rest
+		if next >= 0 {
+			value = rest[:next]
+		}
+
+		value = strings.TrimSpace(value)
+		line := strings.Count(text[:pos], "\n") + 1
+
+		switch tag {
+		case "STMTTRN":
+			transaction, transactionLine = map[string]string{}, line
+		case "/STMTTRN":
+			if transaction == nil {
+				break
+			}
+
+			expense, err := ofxTransaction(transaction, transactionLine, account, currency, options)
+			if err != nil {
+				return nil, err
+			}
+
+			imported = append(imported, expense)
+			transaction = nil
+		case "ACCTID":
+			account = value
+		case "CURDEF":
+			currency = strings.ToUpper(value)
+		default:
+			if transaction != nil && !strings.HasPrefix(tag, "/") && value != "" {
+				// Payees can be in a NAME inside a PAYEE, but that's still a name
+				if _, ok := transaction[tag]; !ok {
+					transaction[tag] = unescapeOFX(value)
+				}
+			}
+		}
+
+		if next < 0 {
+			break
+		}
+
+		pos += end + 1 + next
+	}
+
+	return imported, nil
+}
+
+func unescapeOFX(value string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'").Replace(value)
+}
+
+func ofxTransaction(t map[string]string, line int, account, currency string, options importOptions) (importedExpense, error) {
+	posted := t["DTPOSTED"]
+	if len(posted) < 8 {
+		return importedExpense{}, fmt.Errorf("Line %d: transaction has no valid date!", line)
+	}
+
+	date, err := time.ParseInLocation("20060102", posted[:8], time.Local)
+	if err != nil {
+		return importedExpense{}, fmt.Errorf("Line %d: '%s' is not a valid date!", line, posted)
+	}
+
+	raw := t["TRNAMT"]
+	decimalComma := strings.Contains(raw, ",") && !strings.Contains(raw, ".")
+
+	amount, err := ParseMoney(cleanAmount(raw, decimalComma), currency)
+	if err != nil {
+		return importedExpense{}, fmt.Errorf("Line %d: %v", line, err)
+	}
+
+	description := t["NAME"]
+	if description == "" {
+		description = t["MEMO"]
+	}
+
+	credit := CREDIT_REFUND
+	switch kind := strings.ToUpper(t["TRNTYPE"]); {
+	case slices.Contains(ofxIncomeTypes, kind):
+		credit = CREDIT_INCOME
+	case slices.Contains(ofxTransferTypes, kind):
+		credit = CREDIT_TRANSFER
+	}
+
+	expense := bankTransaction(line, date, description, amount, credit, options)
+
+	if id := t["FITID"]; id != "" {
+		expense.BankID = id
+		if account != "" {
+			expense.BankID = account + ":" + id
+		}
+	}
+
+	return expense, nil
+}
+
+// Kinds of QIF sections that have transactions worth reading
+var qifAccountTypes []string = []string{"type:bank", "type:ccard", "type:cash", "type:oth a", "type:oth l"}
+
+// Layouts QIF dates are tried with, when no format is given. Years written
+// like '26 are read as /26 first
+var qifDateFormats []string = []string{"1/2/2006", "1/2/06", "2006-01-02"}
+
+func parseQIFDate(text string, options importOptions) (time.Time, error) {
+	if options.DateFormat != "" {
+		return time.ParseInLocation(options.DateFormat, text, time.Local)
+	}
+
+	normalized := strings.ReplaceAll(strings.ReplaceAll(text, "'", "/"), " ", "")
+	for _, layout := range qifDateFormats {
+		if date, err := time.ParseInLocation(layout, normalized, time.Local); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("'%s' is not a date like 12/31/2026 (use --date-format for others)", text)
+}
+
+// Reads the transactions in a QIF file. Only bank, card and cash accounts are
+// read, and transfers between accounts are skipped
+func readQIF(r io.Reader, options importOptions) ([]importedExpense, error) {
+	scanner := bufio.NewScanner(r)
+
+	imported := []importedExpense{}
+	reading := true
+
+	fields := map[byte]string{}
+	start := 0
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if text[0] == '!' {
+			header := strings.ToLower(strings.TrimPrefix(text, "!"))
+			if strings.HasPrefix(header, "type:") {
+				reading = slices.Contains(qifAccountTypes, header)
+			} else if header == "account" {
+				// Lists accounts, not transactions
+				reading = false
+			}
+
+			continue
+		}
+
+		if text[0] != '^' {
+			if len(fields) == 0 {
+				start = line
+			}
+
+			// Split lines (S, E and $) repeat, but only the first of each
+			// field is needed
+			if _, ok := fields[text[0]]; !ok {
+				fields[text[0]] = strings.TrimSpace(text[1:])
+			}
+
+			continue
+		}
+
+		record := fields
+		fields = map[byte]string{}
+
+		if !reading || len(record) == 0 {
+			continue
+		}
+
+		date, err := parseQIFDate(record['D'], options)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", start, err)
+		}
+
+		raw, ok := record['T']
+		if !ok {
+			raw = record['U']
+		}
+
+		amount, err := ParseMoney(cleanAmount(raw, options.DecimalComma), config.GetBaseCurrency())
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", start, err)
+		}
+
+		description := record['P']
+		if description == "" {
+			description = record['M']
+		}
+
+		category := record['L']
+		transfer := strings.HasPrefix(category, "[")
+
+		credit := CREDIT_REFUND
+		if transfer {
+			credit = CREDIT_TRANSFER
+		}
+
+		expense := bankTransaction(start, date, description, amount, credit, options)
+		if transfer {
+			// Money going out to other accounts isn't spent either
+			if expense.Skip == "" {
+				expense.Skip = "it's a transfer between accounts"
+			}
+		} else {
+			expense.Category = normalizeCategory(category)
+		}
+
+		imported = append(imported, expense)
+	}
+
+	return imported, scanner.Err()
+}
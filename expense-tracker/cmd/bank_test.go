@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+const testOFX = `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKACCTFROM><ACCTID>42</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260905120000[-3:BRT]<TRNAMT>-12,50<FITID>A1<NAME>Bakery</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20260906<TRNAMT>5.00<FITID>A2<MEMO>Refund</STMTTRN>
+<STMTTRN><TRNTYPE>DIRECTDEP<DTPOSTED>20260910<TRNAMT>1000.00<FITID>A3<NAME>Salary</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+
+func TestReadOFX(t *testing.T) {
+	imported, err := readOFX(strings.NewReader(testOFX), importOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != 3 {
+		t.Fatalf("Expected 3 transactions, got %d", len(imported))
+	}
+
+	expected := []struct {
+		description string
+		amount      string
+		bankID      string
+		skipped     bool
+	}{
+		{"Bakery", "12.50", "42:A1", false},
+		{"Refund", "-5.00", "42:A2", false},
+		{"Salary", "1000.00", "42:A3", false},
+	}
+
+	if imported[2].Kind != KIND_INCOME {
+		t.Errorf("Expected the salary to be income")
+	}
+
+	for idx, e := range expected {
+		got := imported[idx]
+		if got.Description != e.description || got.Amount.Decimal() != e.amount || got.Amount.Currency != "EUR" ||
+			got.BankID != e.bankID || (got.Skip != "") != e.skipped {
+			t.Errorf("Transaction %d: expected %+v, got %+v", idx, e, got)
+		}
+	}
+
+	imported, err = readOFX(strings.NewReader(testOFX), importOptions{SkipCredits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported[1].Skip == "" {
+		t.Errorf("Expected the refund to be skipped")
+	}
+
+	if imported[2].Skip == "" {
+		t.Errorf("Expected the salary to be skipped")
+	}
+}
+
+func TestReadQIF(t *testing.T) {
+	data := "!Account\nNChecking\nTBank\n^\n!Type:Bank\nD9/1'26\nT-1,200.00\nPLandlord\nLHousing\n^\nD09/02/2026\nT-40.00\nL[Savings]\n^\n"
+
+	imported, err := readQIF(strings.NewReader(data), importOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(imported))
+	}
+
+	rent := imported[0]
+	if rent.Description != "Landlord" || rent.Amount != usd(t, "1200") || rent.Category != "housing" || rent.Date.Day() != 1 {
+		t.Errorf("Unexpected first transaction: %+v", rent)
+	}
+
+	if imported[1].Skip == "" {
+		t.Errorf("Expected the transfer to be skipped")
+	}
+}
+
+func TestFindDuplicatesByBankID(t *testing.T) {
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: {Description: "Coffee", Amount: usd(t, "3"), ID: 1, BankID: "42:A1"},
+	}}
+
+	imported := []importedExpense{
+		{Line: 1, Description: "Coffee", Amount: usd(t, "3"), BankID: "42:A1"},
+		{Line: 2, Description: "Coffee", Amount: usd(t, "3"), BankID: "42:A2"},
+		{Line: 3, Description: "Coffee", Amount: usd(t, "3"), BankID: "42:A2"},
+	}
+
+	added, duplicates := e.findDuplicates(imported)
+	if len(added) != 1 || added[0].Line != 2 || len(duplicates) != 2 {
+		t.Errorf("Expected only line 2 to be added, got %+v", added)
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// What expenses without a category are summed up as
+	UNCATEGORIZED = "uncategorized"
+)
+
+// Categories are compared without caring about case or surrounding spaces
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+func getCategory(ctx *cli.Context) (string, error) {
+	category := normalizeCategory(ctx.String("category"))
+	if category == UNCATEGORIZED {
+		return "", fmt.Errorf("'%s' can't be used as a category!", UNCATEGORIZED)
+	}
+
+	return category, nil
+}
+
+// How much was spent in a category
+type CategoryTotal struct {
+	Category string
+	Total    Money
+}
+
+// Sums up the expenses in a period per category, biggest first
+func (e *Expenses) getSummaryByCategory(period Period) ([]CategoryTotal, error) {
+	totals := map[string]Money{}
+	for _, id := range e.getExpenseIDsByDate() {
+		expense := e.Expenses[id]
+		if !period.Contains(expense.CreatedAt) || expense.IsIncome() {
+			continue
+		}
+
+		category := expense.Category
+		if category == "" {
+			category = UNCATEGORIZED
+		}
+
+		amount, err := toBase(expense.Amount, expense.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Can't convert expense %d: %v", id, err)
+		}
+
+		totals[category] = totals[category].Add(amount)
+	}
+
+	summary := make([]CategoryTotal, 0, len(totals))
+	for category, total := range totals {
+		summary = append(summary, CategoryTotal{category, total})
+	}
+
+	slices.SortFunc(summary, func(a, b CategoryTotal) int {
+		return cmp.Or(b.Total.Cmp(a.Total), cmp.Compare(a.Category, b.Category))
+	})
+
+	return summary, nil
+}
+
+// Returns how much a category's expenses in a month go over its limit (zero
+// when exactly at it), and whether they reach it at all
+func (e *Expenses) getAmountOverCategoryLimit(ym YearMonth, category string) (Money, bool, error) {
+	return e.getAmountOver(e.CategoryLimits[category].For(ym), ym, func(expense Expense) bool {
+		return expense.Category == category
+	})
+}
+
+func printSummaryByCategory(period Period) error {
+	summary, err := expenses.getSummaryByCategory(period)
+	if err != nil {
+		return err
+	}
+
+	if len(summary) == 0 {
+		fmt.Println("There are no expenses to summarize!")
+		return nil
+	}
+
+	total := Money{Currency: config.GetBaseCurrency()}
+	for _, c := range summary {
+		total = total.Add(c.Total)
+	}
+
+	fmt.Println("Category         Total        Share")
+	for _, c := range summary {
+		// Refunds can bring the total down to nothing, leaving nothing to
+		// take shares of
+		share := "-"
+		if total.Units > 0 {
+			share = fmt.Sprintf("%5.1f%%", float64(c.Total.Units)*100/float64(total.Units))
+		}
+
+		fmt.Printf("%-16s %-12s %6s\n", c.Category, c.Total, share)
+	}
+
+	fmt.Printf("%-16s %s\n", "Total", total)
+	return nil
+}
+
+func getSummaryGrouping(ctx *cli.Context) (string, error) {
+	by := ctx.String("by")
+	switch by {
+	case "", "category", "month":
+		return by, nil
+	default:
+		return "", errors.New("Summaries can only be grouped by category or month!")
+	}
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Returns what a function prints
+func captureOutput(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(out)
+}
+
+// Sets the global expenses and config for a test, putting them back after
+func useExpenses(t *testing.T, e *Expenses) {
+	t.Helper()
+
+	oldExpenses, oldConfig := expenses, config
+	expenses, config = e, &Config{}
+
+	t.Cleanup(func() {
+		expenses, config = oldExpenses, oldConfig
+	})
+}
+
+func categorized(id uint64, at time.Time, amount Money, category string) Expense {
+	expense := expenseAt(id, at, amount)
+	expense.Category = category
+	return expense
+}
+
+func TestNormalizeCategory(t *testing.T) {
+	tests := map[string]string{
+		"food":        "food",
+		"  Food ":     "food",
+		"EATING OUT":  "eating out",
+		"":            "",
+		"   ":         "",
+		UNCATEGORIZED: UNCATEGORIZED,
+	}
+
+	for category, expected := range tests {
+		if got := normalizeCategory(category); got != expected {
+			t.Errorf("normalizeCategory(%q) = %q, expected %q", category, got, expected)
+		}
+	}
+}
+
+func TestSummaryByCategory(t *testing.T) {
+	useExpenses(t, &Expenses{})
+
+	day := time.Date(2026, time.September, 10, 12, 0, 0, 0, time.Local)
+	salary := categorized(5, day, usd(t, "3000"), "work")
+	salary.Kind = KIND_INCOME
+
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: categorized(1, day, usd(t, "20"), "food"),
+		2: categorized(2, day, usd(t, "30"), "food"),
+		3: categorized(3, day, usd(t, "100"), "rent"),
+		4: categorized(4, day, usd(t, "5"), ""),
+		5: salary,
+		6: categorized(6, day.AddDate(0, 1, 0), usd(t, "500"), "food"),
+	}}
+
+	summary, err := e.getSummaryByCategory(monthPeriod(YearMonth{2026, time.September}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []CategoryTotal{
+		{"rent", usd(t, "100")},
+		{"food", usd(t, "50")},
+		{UNCATEGORIZED, usd(t, "5")},
+	}
+
+	if !slices.Equal(summary, expected) {
+		t.Errorf("Got %v, expected %v", summary, expected)
+	}
+}
+
+func TestListByCategory(t *testing.T) {
+	day := time.Date(2026, time.September, 10, 12, 0, 0, 0, time.Local)
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: categorized(1, day, usd(t, "20"), "food"),
+		2: categorized(2, day.AddDate(0, 0, 1), usd(t, "30"), "rent"),
+		3: categorized(3, day.AddDate(0, 0, 2), usd(t, "5"), ""),
+		4: categorized(4, day.AddDate(0, 1, 0), usd(t, "8"), "food"),
+	}}
+
+	food, uncategorized := "food", ""
+	tests := []struct {
+		filter   listFilter
+		expected []uint64
+	}{
+		{listFilter{}, []uint64{1, 2, 3, 4}},
+		{listFilter{Category: &food}, []uint64{1, 4}},
+		{listFilter{Category: &uncategorized}, []uint64{3}},
+		{listFilter{Category: &food, Period: monthPeriod(YearMonth{2026, time.September})}, []uint64{1}},
+	}
+
+	for _, test := range tests {
+		if got := e.getListedIDs(test.filter); !slices.Equal(got, test.expected) {
+			t.Errorf("Listing %+v got %v, expected %v", test.filter, got, test.expected)
+		}
+	}
+}
+
+func TestCategoryLimitWarning(t *testing.T) {
+	day := time.Date(2026, time.September, 10, 12, 0, 0, 0, time.Local)
+	ym := getYearMonth(day)
+
+	e := &Expenses{
+		Expenses: map[uint64]Expense{
+			1: categorized(1, day, usd(t, "60"), "food"),
+			2: categorized(2, day, usd(t, "500"), "rent"),
+		},
+		CategoryLimits: map[string]Limits{"food": {Default: usd(t, "100")}},
+	}
+
+	useExpenses(t, e)
+
+	if out := captureOutput(t, func() { warnIfOverLimit(ym, "food") }); out != "" {
+		t.Errorf("Warned under the limit: %q", out)
+	}
+
+	// Other categories don't count towards it
+	if out := captureOutput(t, func() { warnIfOverLimit(ym, "rent") }); out != "" {
+		t.Errorf("Warned for a category without a limit: %q", out)
+	}
+
+	e.Expenses[3] = categorized(3, day, usd(t, "40"), "food")
+	out := captureOutput(t, func() { warnIfOverLimit(ym, "food") })
+	if !strings.Contains(out, "exactly at your monthly limit for 'food'") {
+		t.Errorf("Didn't warn at the limit: %q", out)
+	}
+
+	e.Expenses[4] = categorized(4, day, usd(t, "12.5"), "food")
+	out = captureOutput(t, func() { warnIfOverLimit(ym, "food") })
+	if !strings.Contains(out, "over your monthly limit for 'food' by $12.50") {
+		t.Errorf("Didn't warn over the limit: %q", out)
+	}
+
+	// Limits are for a month
+	if out := captureOutput(t, func() { warnIfOverLimit(YearMonth{2026, time.October}, "food") }); out != "" {
+		t.Errorf("Warned for another month: %q", out)
+	}
+}
+
+func TestSummarySharesWithRefunds(t *testing.T) {
+	day := time.Date(2026, time.September, 10, 12, 0, 0, 0, time.Local)
+	useExpenses(t, &Expenses{Expenses: map[uint64]Expense{
+		1: categorized(1, day, usd(t, "80"), "clothes"),
+		2: categorized(2, day, usd(t, "-80"), "clothes"),
+		3: categorized(3, day, usd(t, "-20"), "food"),
+	}})
+
+	out := captureOutput(t, func() {
+		if err := printSummaryByCategory(Period{}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if strings.Contains(out, "NaN") || strings.Contains(out, "Inf") || strings.Contains(out, "%") {
+		t.Errorf("Printed shares of a total that isn't positive:\n%s", out)
+	}
+
+	if !strings.Contains(out, "-$20.00") {
+		t.Errorf("Expected a -$20.00 total:\n%s", out)
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	CONFIG_NAME = "config.json"
+)
+
+type Config struct {
+	// What summaries and limits are in
+	BaseCurrency string `json:"base_currency,omitempty"`
+
+	// Where exchange rates are read from
+	RatesFile string `json:"rates_file,omitempty"`
+
+	// Whether adding expenses warns about going over the limit by the end of
+	// the month, at the pace spending is going
+	PaceWarning bool `json:"pace_warning,omitempty"`
+
+	// Categories for imported expenses, by their descriptions
+	CategoryRules []CategoryRule `json:"category_rules,omitempty"`
+}
+
+var config *Config = &Config{}
+
+// The keys settings are got and set by, in the order they're listed in
+var configKeys []string = []string{"base-currency", "rates-file", "pace-warning"}
+
+func (c *Config) GetBaseCurrency() string {
+	if c.BaseCurrency == "" {
+		return DEFAULT_CURRENCY
+	}
+
+	return c.BaseCurrency
+}
+
+func (c *Config) GetRatesFile() string {
+	if c.RatesFile == "" {
+		return RATES_NAME
+	}
+
+	return c.RatesFile
+}
+
+func (c *Config) Get(key string) (string, error) {
+	switch key {
+	case "base-currency":
+		return c.GetBaseCurrency(), nil
+	case "rates-file":
+		return c.GetRatesFile(), nil
+	case "pace-warning":
+		return strconv.FormatBool(c.PaceWarning), nil
+	default:
+		return "", fmt.Errorf("Unknown setting '%s' (must be one of %s)", key, strings.Join(configKeys, ", "))
+	}
+}
+
+func (c *Config) Set(key, value string) error {
+	switch key {
+	case "base-currency":
+		currency := strings.ToUpper(strings.TrimSpace(value))
+		if !isCurrency(currency) {
+			return fmt.Errorf("'%s' is not a valid currency (must be a code like USD or EUR)", value)
+		}
+
+		c.BaseCurrency = currency
+	case "rates-file":
+		c.RatesFile = value
+	case "pace-warning":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("'pace-warning' must be true or false, not '%s'!", value)
+		}
+
+		c.PaceWarning = enabled
+	default:
+		return fmt.Errorf("Unknown setting '%s' (must be one of %s)", key, strings.Join(configKeys, ", "))
+	}
+
+	return nil
+}
+
+// Loads the config file, if it exists
+func loadConfig() error {
+	config = &Config{}
+
+	file, err := os.ReadFile(CONFIG_NAME)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err := json.Unmarshal(file, config); err != nil {
+		return fmt.Errorf("Error reading config file '%s': %v\n", CONFIG_NAME, err)
+	}
+
+	return nil
+}
+
+func saveConfig() error {
+	data, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return fmt.Errorf("Error marshalling JSON data: %v\n", err)
+	}
+
+	return os.WriteFile(CONFIG_NAME, data, 0644)
+}
+
+func HandleConfigGet(ctx *cli.Context) error {
+	value, err := config.Get(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+func HandleConfigSet(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("Must provide a setting and its value!")
+	}
+
+	key, value := ctx.Args().Get(0), ctx.Args().Get(1)
+	if err := config.Set(key, value); err != nil {
+		return err
+	}
+
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Set '%s' to '%s'\n", key, value)
+	return nil
+}
+
+func HandleConfigList(ctx *cli.Context) error {
+	for _, key := range configKeys {
+		value, _ := config.Get(key)
+		fmt.Printf("%-14s %s\n", key, value)
+	}
+
+	return nil
+}
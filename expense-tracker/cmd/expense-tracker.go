@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"slices"
 	"strconv"
@@ -18,36 +19,60 @@ const (
 )
 
 type Expense struct {
-	CreatedAt   time.Time   `json:"created_at"`
-	Description string      `json:"description"`
-	Amount      json.Number `json:"Amount"`
-	ID          uint64      `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Description string    `json:"description"`
+	Amount      Money     `json:"amount"`
+	Category    string    `json:"category,omitempty"`
+	ID          uint64    `json:"id"`
+
+	// The bank's ID for the transaction, for expenses imported from bank
+	// statements
+	BankID string `json:"bank_id,omitempty"`
+
+	// Whether it's an expense or income
+	Kind string `json:"kind,omitempty"`
+
+	// The recurring entry it's an occurrence of, if it's one
+	RecurringID uint64 `json:"recurring_id,omitempty"`
 }
 
 func (e Expense) String() string {
+	category := e.Category
+	if category == "" {
+		category = "-"
+	}
+
+	// Income is shown as money coming in
+	amount := e.Amount.String()
+	if e.IsIncome() {
+		amount = "+" + amount
+	}
+
 	return fmt.Sprintf(
-		"%-3d %-11s %-12s %s",
+		"%-3d %-11s %-12s %-12s %-13s",
 		e.ID, e.CreatedAt.Format("2006-01-02"),
-		e.Description, e.Amount,
+		e.Description, category, amount,
 	)
 }
 
-func toJSONNumber(amount float64) json.Number {
-	return json.Number(strconv.FormatFloat(amount, 'f', 2, 64))
-}
-
-func createExpense(id uint64, desc string, amount float64) Expense {
+func createExpense(id uint64, date time.Time, desc string, amount Money, category string) Expense {
 	return Expense{
-		CreatedAt:   time.Now(),
+		CreatedAt:   date,
 		Description: desc,
-		Amount:      toJSONNumber(amount),
+		Amount:      amount,
+		Category:    category,
 		ID:          id,
 	}
 }
 
 type Expenses struct {
 	Expenses map[uint64]Expense `json:"expenses"`
-	Limits   [12]float64        `json:"limits"`
+	Limits   Limits             `json:"limits"`
+
+	// Monthly limits for single categories
+	CategoryLimits map[string]Limits `json:"category_limits,omitempty"`
+
+	Recurring map[uint64]Recurring `json:"recurring,omitempty"`
 }
 
 var expenses *Expenses
@@ -62,29 +87,56 @@ func (e *Expenses) getSortedExpenseIDs() []uint64 {
 	return ids
 }
 
-const (
-	NOT_OVER      = -1.0
-	EXACTLY_EQUAL = 0.0
-)
+// Returns the IDs of the expenses, oldest first
+func (e *Expenses) getExpenseIDsByDate() []uint64 {
+	ids := e.getSortedExpenseIDs()
+	slices.SortStableFunc(ids, func(a, b uint64) int {
+		return e.Expenses[a].CreatedAt.Compare(e.Expenses[b].CreatedAt)
+	})
+
+	return ids
+}
 
-func (e *Expenses) getAmountOverLimit(month time.Month) float64 {
-	if e.Limits[month] == 0 {
-		return NOT_OVER
+// Returns how much the expenses in a month that pass a filter go over a limit
+// (zero when exactly at it), and whether they reach it at all
+func (e *Expenses) getAmountOver(limit Money, ym YearMonth, keep func(Expense) bool) (Money, bool, error) {
+	if limit.IsZero() {
+		return Money{}, false, nil
 	}
 
-	total := e.getSummaryByMonth(month)
-	if total < e.Limits[month] {
-		return NOT_OVER
+	period := monthPeriod(ym)
+
+	// Limits are in the base currency, but it might have changed since
+	limit, err := toBase(limit, period.From)
+	if err != nil {
+		return Money{}, false, err
 	}
 
-	if total == e.Limits[month] {
-		return EXACTLY_EQUAL
+	total, err := e.sum(func(expense Expense) bool {
+		return period.Contains(expense.CreatedAt) && !expense.IsIncome() && keep(expense)
+	})
+
+	if err != nil {
+		return Money{}, false, err
+	}
+
+	if total.Cmp(limit) < 0 {
+		return Money{}, false, nil
 	}
 
-	return total - e.Limits[month]
+	return total.Sub(limit), true, nil
 }
 
-func (e *Expenses) addExpense(desc string, amount float64) uint64 {
+// Returns how much the month's expenses go over its limit (zero when exactly
+// at it), and whether they reach it at all
+func (e *Expenses) getAmountOverLimit(ym YearMonth) (Money, bool, error) {
+	return e.getAmountOver(e.Limits.For(ym), ym, func(Expense) bool {
+		return true
+	})
+}
+
+// Returns the lowest ID that isn't taken
+func (e *Expenses) getFreeID() uint64 {
 	ids := e.getSortedExpenseIDs()
 
 	id := uint64(1)
@@ -104,8 +156,12 @@ func (e *Expenses) addExpense(desc string, amount float64) uint64 {
 		}
 	}
 
-	e.Expenses[id] = createExpense(id, desc, amount)
-	fmt.Printf("Expense added successfully (ID: %v)\n", id)
+	return id
+}
+
+func (e *Expenses) addExpense(date time.Time, desc string, amount Money, category string) uint64 {
+	id := e.getFreeID()
+	e.Expenses[id] = createExpense(id, date, desc, amount, category)
 
 	return id
 }
@@ -119,32 +175,41 @@ func (e *Expenses) deleteExpense(id uint64) error {
 	return nil
 }
 
-func (e *Expenses) getSummary() float64 {
-	total := 0.0
-	for _, expense := range expenses.Expenses {
-		v, _ := expense.Amount.Float64()
-		total += v
-	}
-
-	return total
-}
-
-func (e *Expenses) getSummaryByMonth(month time.Month) float64 {
-	total := 0.0
-	for _, expense := range expenses.Expenses {
-		if expense.CreatedAt.Month() != month {
+// Sums up the expenses that pass a filter, converted into the base currency
+// at the rates of their dates
+func (e *Expenses) sum(keep func(Expense) bool) (Money, error) {
+	total := Money{Currency: config.GetBaseCurrency()}
+	for _, id := range e.getExpenseIDsByDate() {
+		expense := e.Expenses[id]
+		if !keep(expense) {
 			continue
 		}
 
-		v, _ := expense.Amount.Float64()
-		total += v
+		amount, err := toBase(expense.Amount, expense.CreatedAt)
+		if err != nil {
+			return Money{}, fmt.Errorf("Can't convert expense %d: %v", id, err)
+		}
+
+		total = total.Add(amount)
 	}
 
-	return total
+	return total, nil
+}
+
+// Sums up the expenses in a period (a zero one covers all of them), leaving
+// income out
+func (e *Expenses) getSummary(period Period) (Money, error) {
+	return e.sum(func(expense Expense) bool {
+		return period.Contains(expense.CreatedAt) && !expense.IsIncome()
+	})
 }
 
 // Loads a saved JSON database, if it exists
 func Load(ctx *cli.Context) error {
+	if err := loadConfig(); err != nil {
+		return err
+	}
+
 	expenses = &Expenses{
 		Expenses: make(map[uint64]Expense, 0),
 	}
@@ -158,6 +223,7 @@ func Load(ctx *cli.Context) error {
 		return fmt.Errorf("Error unmarshalling JSON data: %v\n", err)
 	}
 
+	printAddedRecurring(expenses.addDueRecurring(time.Now()))
 	return nil
 }
 
@@ -179,15 +245,81 @@ func Save(ctx *cli.Context) error {
 	return nil
 }
 
-func getAmount(ctx *cli.Context) (float64, error) {
-	amount := ctx.Float64("amount")
-	if amount <= 0.0 {
-		return 0.0, errors.New("An expense must have a positive, non-zero amount!")
+// Returns the amount given by --amount, in the currency given by --currency
+// (or else the one given)
+func getAmount(ctx *cli.Context, currency string) (Money, error) {
+	if ctx.IsSet("currency") {
+		currency = ctx.String("currency")
+	}
+
+	amount, err := ParseMoney(ctx.String("amount"), currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if amount.Units <= 0 {
+		return Money{}, errors.New("An expense must have a positive, non-zero amount!")
 	}
 
 	return amount, nil
 }
 
+// Returns the date given by a flag, or else today. Expenses made today keep
+// the time they were added at, so they stay in order
+func getDate(ctx *cli.Context, flag string) (time.Time, error) {
+	now := time.Now()
+	if !ctx.IsSet(flag) {
+		return now, nil
+	}
+
+	date, err := parseDate(ctx.String(flag), now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if date.Equal(startOfDay(now)) {
+		return now, nil
+	}
+
+	return date, nil
+}
+
+// Warns when a month's expenses reach its limit, or the limit of the
+// expense's category
+func warnIfOverLimit(ym YearMonth, category string) {
+	over, ok, err := expenses.getAmountOverLimit(ym)
+	if err != nil {
+		fmt.Printf("Warning! Couldn't check your spending limits: %v\n", err)
+		return
+	}
+
+	if ok {
+		if over.IsZero() {
+			fmt.Println("Warning! This expense puts you exactly at your monthly spending limit!")
+		} else {
+			fmt.Printf("Warning! This expense puts you over your your monthly spending limit by %s!\n", over)
+		}
+	}
+
+	if category == "" {
+		return
+	}
+
+	over, ok, err = expenses.getAmountOverCategoryLimit(ym, category)
+	if err != nil {
+		fmt.Printf("Warning! Couldn't check your spending limit for '%s': %v\n", category, err)
+		return
+	}
+
+	if ok {
+		if over.IsZero() {
+			fmt.Printf("Warning! This expense puts you exactly at your monthly limit for '%s'!\n", category)
+		} else {
+			fmt.Printf("Warning! This expense puts you over your monthly limit for '%s' by %s!\n", category, over)
+		}
+	}
+}
+
 func HandleAdd(ctx *cli.Context) error {
 	description := ctx.String("description")
 	if description == "" {
@@ -198,26 +330,41 @@ func HandleAdd(ctx *cli.Context) error {
 		return errors.New("Must provide the amount of the expense!")
 	}
 
-	amount := ctx.Float64("amount")
-	if amount <= 0.0 {
-		return errors.New("An expense must have a positive, non-zero amount!")
+	amount, err := getAmount(ctx, config.GetBaseCurrency())
+	if err != nil {
+		return err
 	}
 
-	id := expenses.addExpense(description, amount)
+	category, err := getCategory(ctx)
+	if err != nil {
+		return err
+	}
 
-	month := expenses.Expenses[id].CreatedAt.Month()
+	date, err := getDate(ctx, "date")
+	if err != nil {
+		return err
+	}
 
-	over := expenses.getAmountOverLimit(month)
-	if over == NOT_OVER {
-		return nil
+	kind, err := getKind(ctx)
+	if err != nil {
+		return err
 	}
 
-	if over == EXACTLY_EQUAL {
-		fmt.Println("Warning! This expense puts you exactly at your monthly spending limit!")
-	} else {
-		fmt.Printf("Warning! This expense puts you over your your monthly spending limit by $%.2f!\n", over)
+	id := expenses.addExpense(date, description, amount, category)
+
+	if kind == KIND_INCOME {
+		expense := expenses.Expenses[id]
+		expense.Kind = kind
+		expenses.Expenses[id] = expense
+
+		fmt.Printf("Income added successfully (ID: %v)\n", id)
+		return nil
 	}
 
+	fmt.Printf("Expense added successfully (ID: %v)\n", id)
+	warnIfOverLimit(getYearMonth(expenses.Expenses[id].CreatedAt), category)
+	warnIfAheadOfPace(getYearMonth(expenses.Expenses[id].CreatedAt), category)
+
 	return nil
 }
 
@@ -233,25 +380,55 @@ func HandleUpdate(ctx *cli.Context) error {
 		}
 
 		if ctx.IsSet("amount") {
-			amount, err := getAmount(ctx)
+			// Amounts stay in their currency, unless told otherwise
+			amount, err := getAmount(ctx, expense.Amount.Currency)
+			if err != nil {
+				return err
+			}
+
+			expense.Amount = amount
+		} else if ctx.IsSet("currency") {
+			// Same amount, other currency
+			amount, err := ParseMoney(expense.Amount.Decimal(), ctx.String("currency"))
 			if err != nil {
 				return err
 			}
 
-			expense.Amount = toJSONNumber(amount)
+			expense.Amount = amount
 		}
 
-		expenses.Expenses[id] = expense
+		// An empty category takes it away
+		if ctx.IsSet("category") {
+			category, err := getCategory(ctx)
+			if err != nil {
+				return err
+			}
 
-		over := expenses.getAmountOverLimit(expense.CreatedAt.Month())
-		if over == NOT_OVER {
-			return nil
+			expense.Category = category
 		}
 
-		if over == EXACTLY_EQUAL {
-			fmt.Println("Warning! This expense puts you exactly at your monthly spending limit!")
-		} else {
-			fmt.Printf("Warning! This expense puts you over your your monthly spending limit by $%.2f!\n", over)
+		if ctx.IsSet("date") {
+			date, err := getDate(ctx, "date")
+			if err != nil {
+				return err
+			}
+
+			expense.CreatedAt = date
+		}
+
+		if ctx.IsSet("type") {
+			kind, err := getKind(ctx)
+			if err != nil {
+				return err
+			}
+
+			expense.Kind = kind
+		}
+
+		expenses.Expenses[id] = expense
+		if !expense.IsIncome() {
+			warnIfOverLimit(getYearMonth(expense.CreatedAt), expense.Category)
+			warnIfAheadOfPace(getYearMonth(expense.CreatedAt), expense.Category)
 		}
 
 		return nil
@@ -260,33 +437,145 @@ func HandleUpdate(ctx *cli.Context) error {
 	return fmt.Errorf("No expense with ID %v!\n", id)
 }
 
+// Returns the balance after each entry, going over all of them by date, in
+// the base currency. Income adds to it and expenses take away from it
+//
+// It can't go on past an entry that can't be converted, so then only the
+// balances up to it are there, and the error is returned too
+func (e *Expenses) getRunningBalances() (map[uint64]Money, error) {
+	balance := Money{Currency: config.GetBaseCurrency()}
+	balances := make(map[uint64]Money, len(e.Expenses))
+
+	for _, id := range e.getExpenseIDsByDate() {
+		expense := e.Expenses[id]
+
+		amount, err := toBase(expense.signedAmount(), expense.CreatedAt)
+		if err != nil {
+			return balances, fmt.Errorf("Can't convert entry %d: %v", id, err)
+		}
+
+		balance = balance.Add(amount)
+		balances[id] = balance
+	}
+
+	return balances, nil
+}
+
+// Which entries list shows. Nil fields don't filter anything
+type listFilter struct {
+	Category *string
+	Kind     *string
+	Period   Period
+}
+
+// Returns the IDs of the entries that pass a filter, by date
+func (e *Expenses) getListedIDs(filter listFilter) []uint64 {
+	return slices.DeleteFunc(e.getExpenseIDsByDate(), func(id uint64) bool {
+		expense := e.Expenses[id]
+		if filter.Category != nil && expense.Category != *filter.Category {
+			return true
+		}
+
+		if filter.Kind != nil && expense.Kind != *filter.Kind {
+			return true
+		}
+
+		return !filter.Period.Contains(expense.CreatedAt)
+	})
+}
+
 func HandleList(ctx *cli.Context) error {
-	fmt.Println("ID  Date        Description  Amount")
+	category := normalizeCategory(ctx.String("category"))
+	if category == UNCATEGORIZED {
+		category = ""
+	}
+
+	period, err := getPeriod(ctx)
+	if err != nil {
+		return err
+	}
+
+	kind := ""
+	if ctx.IsSet("type") {
+		kind, err = getKind(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	filter := listFilter{Period: period}
+	if ctx.IsSet("category") {
+		filter.Category = &category
+	}
+
+	if ctx.IsSet("type") {
+		filter.Kind = &kind
+	}
+
+	ids := expenses.getListedIDs(filter)
 
-	ids := expenses.getSortedExpenseIDs()
 	if len(ids) == 0 {
 		fmt.Println("There are no expenses to display!")
 		return nil
 	}
 
+	// The balance counts every entry, not just the ones listed
+	balances, balanceErr := expenses.getRunningBalances()
+	missing := false
+
+	fmt.Println("ID  Date        Description  Category     Amount        Balance")
 	for _, id := range ids {
-		fmt.Println(expenses.Expenses[id].String())
+		if balance, ok := balances[id]; ok {
+			fmt.Printf("%s %s\n", expenses.Expenses[id], balance)
+		} else {
+			fmt.Printf("%s %s\n", expenses.Expenses[id], "?")
+			missing = true
+		}
+	}
+
+	if missing {
+		fmt.Printf("Warning! %v\n", balanceErr)
 	}
 
 	return nil
 }
 
 func HandleSummary(ctx *cli.Context) error {
-	if ctx.IsSet("month") {
-		month := ctx.Int("month")
-		total := expenses.getSummaryByMonth(time.Month(month))
-		fmt.Printf("Total expenses for %s: $%.2f\n", time.Month(month), total)
+	by, err := getSummaryGrouping(ctx)
+	if err != nil {
+		return err
+	}
+
+	period, err := getPeriod(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch by {
+	case "category":
+		return printSummaryByCategory(period)
+	case "month":
+		return printMonthlyBalances(period)
+	}
+
+	total, err := expenses.getSummary(period)
+	if err != nil {
+		return err
+	}
+
+	if period.IsZero() {
+		fmt.Printf("Total expenses: %s\n", total)
 	} else {
-		total := expenses.getSummary()
-		fmt.Printf("Total expenses: $%.2f\n", total)
+		fmt.Printf("Total expenses %s: %s\n", period.Name, total)
 	}
 
-	return nil
+	// Only worth a table when there's income to weigh the expenses against
+	if !expenses.hasIncome(period) {
+		return nil
+	}
+
+	fmt.Println()
+	return printMonthlyBalances(period)
 }
 
 func HandleDelete(ctx *cli.Context) error {
@@ -308,67 +597,100 @@ func HandleDelete(ctx *cli.Context) error {
 	return nil
 }
 
+// Sets a limit for a month, or a default one for all months (when no month
+// is given)
 func HandleSetLimit(ctx *cli.Context) error {
 	if !ctx.IsSet("amount") {
 		return errors.New("Must provide a limit amount!")
 	}
 
-	amount, err := getAmount(ctx)
+	amount, err := getAmount(ctx, config.GetBaseCurrency())
 	if err != nil {
 		return err
 	}
 
-	if ctx.IsSet("month") {
-		month := ctx.Int("month")
-		if month < 1 || month > 12 {
-			return fmt.Errorf("'%d' is not a valid month (must be range from 1-12)\n", month)
+	category := ""
+	if ctx.IsSet("category") {
+		category, err = getCategory(ctx)
+		if err != nil {
+			return err
 		}
 
-		expenses.Limits[month-1] = amount
-		fmt.Println("Set monthly spending limit successfully")
-
-		return nil
+		if category == "" {
+			return errors.New("Must provide the category the limit applies to!")
+		}
 	}
 
-	for month := range 12 {
-		expenses.Limits[month] = amount
+	limits := expenses.Limits
+	name := "spending limit"
+
+	if category != "" {
+		limits = expenses.CategoryLimits[category]
+		name = fmt.Sprintf("spending limit for '%s'", category)
 	}
 
-	fmt.Println("Set spending limit successfully")
-	return nil
-}
+	if ctx.IsSet("month") {
+		ym, err := ParseYearMonth(ctx.String("month"))
+		if err != nil {
+			return err
+		}
 
-func HandleSave(ctx *cli.Context) error {
-	outputFile := ctx.String("output")
-	if outputFile == "" {
-		outputFile = "output.csv"
+		limits.Set(ym, amount)
+		fmt.Printf("Set %s for %s %d successfully\n", name, ym.Month, ym.Year)
+	} else {
+		limits.Default = amount
+		fmt.Printf("Set monthly %s successfully\n", name)
 	}
 
-	file, err := os.Create(outputFile)
-	if err != nil {
-		return err
+	if category == "" {
+		expenses.Limits = limits
+		return nil
 	}
 
-	defer file.Close()
+	if expenses.CategoryLimits == nil {
+		expenses.CategoryLimits = map[string]Limits{}
+	}
 
-	writer := csv.NewWriter(file)
-	writer.Write([]string{"id", "created_at", "description", "amount"})
+	expenses.CategoryLimits[category] = limits
+	return nil
+}
+
+// Writes the expenses as CSV, in a format import reads back as they were
+func (e *Expenses) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "created_at", "description", "category", "amount", "currency", "kind", "bank_id"})
 
-	ids := expenses.getSortedExpenseIDs()
+	ids := e.getSortedExpenseIDs()
 	for _, id := range ids {
-		expense := expenses.Expenses[id]
+		expense := e.Expenses[id]
 		writer.Write([]string{
 			strconv.FormatUint(expense.ID, 10),
 			expense.CreatedAt.Format("2006-01-02"),
 			expense.Description,
-			expense.Amount.String(),
+			expense.Category,
+			expense.Amount.Decimal(),
+			expense.Amount.Currency,
+			expense.Kind,
+			expense.BankID,
 		})
 	}
 
 	writer.Flush()
-	if err := writer.Error(); err != nil {
+	return writer.Error()
+}
+
+func HandleSave(ctx *cli.Context) error {
+	outputFile := ctx.String("output")
+	if outputFile == "" {
+		outputFile = "output.csv"
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	defer file.Close()
+
+	return expenses.writeCSV(file)
 }
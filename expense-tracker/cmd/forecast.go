@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Where spending in a month is headed
+type Forecast struct {
+	Month    YearMonth
+	Category string
+
+	// Spent so far, including expenses dated later in the month
+	Spent Money
+
+	// Still to come from recurring entries
+	Recurring Money
+
+	// Spent by the end of the month, if spending goes on at the same pace
+	Projected Money
+
+	// Zero when there's no limit
+	Limit Money
+
+	DaysPassed  int
+	DaysInMonth int
+}
+
+// Projects where spending in a month (in a category, if one is given) is
+// headed, as of some time
+//
+// Expenses that aren't recurring are taken to go on at the pace they went on
+// so far in the month. Recurring ones are added as they're scheduled instead
+func (e *Expenses) getForecast(ym YearMonth, category string, now time.Time) (Forecast, error) {
+	period := monthPeriod(ym)
+	base := config.GetBaseCurrency()
+
+	f := Forecast{
+		Month:       ym,
+		Category:    category,
+		Spent:       Money{Currency: base},
+		Recurring:   Money{Currency: base},
+		DaysInMonth: daysIn(ym.Year, ym.Month),
+	}
+
+	switch today := startOfDay(now); {
+	case today.Before(period.From):
+		f.DaysPassed = 0
+	case !today.Before(period.To):
+		f.DaysPassed = f.DaysInMonth
+	default:
+		f.DaysPassed = today.Day()
+	}
+
+	inCategory := func(c string) bool {
+		return category == "" || c == category
+	}
+
+	limits := e.Limits
+	if category != "" {
+		limits = e.CategoryLimits[category]
+	}
+
+	limit, err := toBase(limits.For(ym), period.From)
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	f.Limit = limit
+
+	// Spending that sets the pace
+	paced := Money{Currency: base}
+	endOfToday := startOfDay(now).AddDate(0, 0, 1)
+
+	for _, id := range e.getExpenseIDsByDate() {
+		expense := e.Expenses[id]
+		if expense.IsIncome() || !period.Contains(expense.CreatedAt) || !inCategory(expense.Category) {
+			continue
+		}
+
+		amount, err := toBase(expense.Amount, expense.CreatedAt)
+		if err != nil {
+			return Forecast{}, fmt.Errorf("Can't convert expense %d: %v", id, err)
+		}
+
+		f.Spent = f.Spent.Add(amount)
+		if expense.RecurringID == 0 && expense.CreatedAt.Before(endOfToday) {
+			paced = paced.Add(amount)
+		}
+	}
+
+	for _, u := range e.getUpcoming(now, period.To.AddDate(0, 0, -1)) {
+		if u.Recurring.Kind == KIND_INCOME || !period.Contains(u.Date) || !inCategory(u.Recurring.Category) {
+			continue
+		}
+
+		amount, err := toBase(u.Recurring.Amount, u.Date)
+		if err != nil {
+			return Forecast{}, fmt.Errorf("Can't convert recurring entry %d: %v", u.Recurring.ID, err)
+		}
+
+		f.Recurring = f.Recurring.Add(amount)
+	}
+
+	f.Projected = f.Spent.Add(f.Recurring)
+	if f.DaysPassed > 0 {
+		f.Projected = f.Projected.Add(paced.Scale(int64(f.DaysInMonth-f.DaysPassed), int64(f.DaysPassed)))
+	}
+
+	return f, nil
+}
+
+// Returns what's left of the limit after what's spent and still to come from
+// recurring entries, and how much of it can be spent per day for the rest of
+// the month (counting today)
+func (f Forecast) Allowance() (Money, Money) {
+	left := f.Limit.Sub(f.Spent).Sub(f.Recurring)
+
+	days := f.DaysInMonth - f.DaysPassed + 1
+	if f.DaysPassed == 0 {
+		days = f.DaysInMonth
+	}
+
+	if days < 1 || left.Units <= 0 {
+		return left, Money{Currency: left.Currency}
+	}
+
+	return left, left.Scale(1, int64(days))
+}
+
+// Warns when spending in the month of an expense goes at a pace that would
+// take it over the limit, or the limit of the expense's category. It's only
+// checked if it's turned on
+func warnIfAheadOfPace(ym YearMonth, category string) {
+	if !config.PaceWarning {
+		return
+	}
+
+	checkPace(ym, "")
+	if category != "" {
+		checkPace(ym, category)
+	}
+}
+
+func checkPace(ym YearMonth, category string) {
+	f, err := expenses.getForecast(ym, category, time.Now())
+	if err != nil || f.Limit.IsZero() {
+		return
+	}
+
+	// Reaching the limit is warned about already
+	if f.Spent.Cmp(f.Limit) >= 0 || f.Projected.Cmp(f.Limit) <= 0 {
+		return
+	}
+
+	name := "your monthly spending limit"
+	if category != "" {
+		name = fmt.Sprintf("your monthly limit for '%s'", category)
+	}
+
+	fmt.Printf(
+		"Warning! You've spent %d%% of %s by day %d. At this pace, you'll spend %s this month, %s over it!\n",
+		f.Spent.Units*100/f.Limit.Units, name, f.DaysPassed, f.Projected, f.Projected.Sub(f.Limit),
+	)
+}
+
+func HandleForecast(ctx *cli.Context) error {
+	now := time.Now()
+
+	ym := getYearMonth(now)
+	if ctx.IsSet("month") {
+		var err error
+		if ym, err = ParseYearMonth(ctx.String("month")); err != nil {
+			return err
+		}
+	}
+
+	category, err := getCategory(ctx)
+	if err != nil {
+		return err
+	}
+
+	f, err := expenses.getForecast(ym, category, now)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("Forecast for %s %d", ym.Month, ym.Year)
+	if category != "" {
+		title += fmt.Sprintf(" ('%s')", category)
+	}
+
+	fmt.Println(title)
+	fmt.Printf("%-24s %s (day %d of %d)\n", "Spent so far:", f.Spent, f.DaysPassed, f.DaysInMonth)
+	fmt.Printf("%-24s %s\n", "Recurring still due:", f.Recurring)
+	fmt.Printf("%-24s %s\n", "Projected by month end:", f.Projected)
+
+	if f.Limit.IsZero() {
+		fmt.Println("There's no limit set for this month")
+		return nil
+	}
+
+	fmt.Printf("%-24s %s\n", "Limit:", f.Limit)
+
+	if over := f.Projected.Sub(f.Limit); over.Units > 0 {
+		fmt.Printf("At this pace, you'll go over it by %s\n", over)
+	} else {
+		fmt.Printf("At this pace, you'll stay %s under it\n", f.Limit.Sub(f.Projected))
+	}
+
+	left, daily := f.Allowance()
+	if left.Units <= 0 {
+		fmt.Printf("There's nothing left to spend (%s over)\n", Money{Units: -left.Units, Currency: left.Currency})
+	} else if startOfDay(now).Before(monthPeriod(ym).To) {
+		fmt.Printf("You can spend %s more, or %s a day\n", left, daily)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecast(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2026, time.September, day, 0, 0, 0, 0, time.Local)
+	}
+
+	rent := expenseAt(3, date(1), usd(t, "500"))
+	rent.RecurringID = 1
+
+	salary := expenseAt(4, date(5), usd(t, "3000"))
+	salary.Kind = KIND_INCOME
+
+	e := &Expenses{
+		Expenses: map[uint64]Expense{
+			1: expenseAt(1, date(2), usd(t, "200")),
+			2: expenseAt(2, date(9), usd(t, "100")),
+			3: rent,
+			4: salary,
+		},
+		Limits: Limits{Default: usd(t, "1000")},
+		Recurring: map[uint64]Recurring{
+			1: {ID: 1, Description: "Rent", Amount: usd(t, "500"), Every: EVERY_MONTH, Day: 1, Next: time.Date(2026, time.October, 1, 0, 0, 0, 0, time.Local)},
+			2: {ID: 2, Description: "Phone", Amount: usd(t, "50"), Every: EVERY_MONTH, Day: 25, Next: date(25)},
+		},
+	}
+
+	f, err := e.getForecast(YearMonth{2026, time.September}, "", date(10).Add(12*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rent doesn't set the pace, only the $300 spent in 10 days does
+	if f.Spent != usd(t, "800") || f.Recurring != usd(t, "50") || f.Projected != usd(t, "1450") {
+		t.Errorf("Expected $800 spent, $50 still due and $1450 projected, got %+v", f)
+	}
+
+	left, daily := f.Allowance()
+	if left != usd(t, "150") || daily != usd(t, "7.14") {
+		t.Errorf("Expected $150 left, or $7.14 a day, got %s and %s", left, daily)
+	}
+}
@@ -0,0 +1,480 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Formats files can be imported from
+var importFormats []string = []string{"csv", "ofx", "qfx", "qif"}
+
+// An expense read from a file, not added yet
+type importedExpense struct {
+	Line        int
+	Date        time.Time
+	Description string
+	Amount      Money
+	Category    string
+	BankID      string
+	Kind        string
+
+	// Why it isn't imported, if it isn't
+	Skip string
+}
+
+func (e importedExpense) String() string {
+	category := e.Category
+	if category == "" {
+		category = "-"
+	}
+
+	amount := e.Amount.String()
+	if e.Kind == KIND_INCOME {
+		amount = "+" + amount
+	}
+
+	line := fmt.Sprintf(
+		"%-5d %-11s %-24s %-12s %s",
+		e.Line, e.Date.Format(DATE_FORMAT),
+		e.Description, category, amount,
+	)
+
+	if e.Skip != "" {
+		line += " (" + e.Skip + ")"
+	}
+
+	return line
+}
+
+// Expenses are taken to be the same if they're on the same day, for the same
+// amount and have the same description. An expense and an income never are
+type expenseKey struct {
+	date        string
+	amount      Money
+	description string
+	kind        string
+}
+
+func getExpenseKey(date time.Time, description string, amount Money, kind string) expenseKey {
+	return expenseKey{
+		date:        date.In(time.Local).Format(DATE_FORMAT),
+		amount:      amount,
+		description: strings.ToLower(strings.TrimSpace(description)),
+		kind:        kind,
+	}
+}
+
+// How to read a file to import
+type importOptions struct {
+	DateColumn        string
+	DescriptionColumn string
+	AmountColumn      string
+	CategoryColumn    string
+	CurrencyColumn    string
+
+	DateFormat   string
+	DecimalComma bool
+	Separator    rune
+	NoHeader     bool
+
+	// Money going out is negative, and money coming in positive, like in
+	// most bank exports. Otherwise it's the other way around, like in the
+	// files this tool saves
+	Negate bool
+
+	// Don't take money coming in as refunds, in bank statements
+	SkipCredits bool
+}
+
+// Returns where a column is, given its name in the header or its number
+// (starting at 1). Optional columns that aren't there are at -1
+func findColumn(header []string, column string, names []string, required bool) (int, error) {
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 {
+			return -1, fmt.Errorf("'%d' is not a valid column number (they start at 1)", n)
+		}
+
+		return n - 1, nil
+	}
+
+	if column != "" {
+		names = []string{column}
+	}
+
+	for _, name := range names {
+		idx := slices.IndexFunc(header, func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), name)
+		})
+
+		if idx >= 0 {
+			return idx, nil
+		}
+	}
+
+	if !required {
+		return -1, nil
+	}
+
+	if header == nil {
+		return -1, fmt.Errorf("Files without a header need the number of the %s column!", names[0])
+	}
+
+	return -1, fmt.Errorf("No '%s' column in the file!", strings.Join(names, "' or '"))
+}
+
+// Turns amounts like "$1,234.50" (or "1.234,50", with decimal commas) into
+// plain decimals
+func cleanAmount(amount string, decimalComma bool) string {
+	amount = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r == '-', r == '+', r == '.', r == ',':
+			return r
+		default:
+			return -1
+		}
+	}, amount)
+
+	if decimalComma {
+		return strings.ReplaceAll(strings.ReplaceAll(amount, ".", ""), ",", ".")
+	}
+
+	return strings.ReplaceAll(amount, ",", "")
+}
+
+// Reads expenses from a CSV file
+//
+// By default, columns are found by their names in the header: "date" (or
+// "created_at", for files saved by this tool), "description", "amount",
+// "category" and "currency". Only the first three have to be there. Negative
+// amounts are taken as refunds, unless the file has them the other way around
+//
+// Files saved by this tool also have "kind" and "bank_id" columns, so that
+// income and bank statement entries come back as they were
+func readCSV(r io.Reader, options importOptions) ([]importedExpense, error) {
+	if options.DateFormat == "" {
+		options.DateFormat = DATE_FORMAT
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = options.Separator
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var header []string
+
+	if !options.NoHeader {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		header = record
+	}
+
+	type column struct {
+		idx      *int
+		flag     string
+		names    []string
+		required bool
+	}
+
+	dateIdx, descIdx, amountIdx, categoryIdx, currencyIdx, kindIdx, bankIDIdx := 0, 0, 0, 0, 0, 0, 0
+	columns := []column{
+		{&dateIdx, options.DateColumn, []string{"date", "created_at"}, true},
+		{&descIdx, options.DescriptionColumn, []string{"description"}, true},
+		{&amountIdx, options.AmountColumn, []string{"amount"}, true},
+		{&categoryIdx, options.CategoryColumn, []string{"category"}, options.CategoryColumn != ""},
+		{&currencyIdx, options.CurrencyColumn, []string{"currency"}, options.CurrencyColumn != ""},
+		{&kindIdx, "", []string{"kind"}, false},
+		{&bankIDIdx, "", []string{"bank_id"}, false},
+	}
+
+	for _, c := range columns {
+		idx, err := findColumn(header, c.flag, c.names, c.required)
+		if err != nil {
+			return nil, err
+		}
+
+		*c.idx = idx
+	}
+
+	get := func(record []string, idx int) string {
+		if idx < 0 || idx >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[idx])
+	}
+
+	imported := []importedExpense{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		// Blank lines are skipped by the reader, but not lines of empty cells
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		date, err := time.ParseInLocation(options.DateFormat, get(record, dateIdx), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: '%s' is not a date like %s!", line, get(record, dateIdx), options.DateFormat)
+		}
+
+		currency := get(record, currencyIdx)
+		if currency == "" {
+			currency = config.GetBaseCurrency()
+		}
+
+		amount, err := ParseMoney(cleanAmount(get(record, amountIdx), options.DecimalComma), currency)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", line, err)
+		}
+
+		if options.Negate {
+			amount.Units = -amount.Units
+		}
+
+		// Negative amounts are refunds, like the ones bank statements bring in
+		if amount.IsZero() {
+			return nil, fmt.Errorf("Line %d: an expense can't have a zero amount!", line)
+		}
+
+		description := get(record, descIdx)
+		if description == "" {
+			description = "an expense"
+		}
+
+		category := normalizeCategory(get(record, categoryIdx))
+		if category == UNCATEGORIZED {
+			category = ""
+		}
+
+		kind, err := parseKind(get(record, kindIdx))
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", line, err)
+		}
+
+		imported = append(imported, importedExpense{
+			Line:        line,
+			Date:        date,
+			Description: description,
+			Amount:      amount,
+			Category:    category,
+			BankID:      get(record, bankIDIdx),
+			Kind:        kind,
+		})
+	}
+
+	return imported, nil
+}
+
+// Splits imported expenses into the new ones and the ones that are already
+// there
+//
+// Expenses from bank statements are matched by the bank's IDs for them. The
+// rest are matched by date, amount and description, with each existing expense
+// only matching a single imported one, so that files with the same expense
+// twice still get both added once
+func (e *Expenses) findDuplicates(imported []importedExpense) ([]importedExpense, []importedExpense) {
+	existing := map[expenseKey]int{}
+	bankIDs := map[string]bool{}
+
+	for _, expense := range e.Expenses {
+		existing[getExpenseKey(expense.CreatedAt, expense.Description, expense.Amount, expense.Kind)]++
+		if expense.BankID != "" {
+			bankIDs[expense.BankID] = true
+		}
+	}
+
+	added, duplicates := []importedExpense{}, []importedExpense{}
+	for _, expense := range imported {
+		if expense.BankID != "" {
+			if bankIDs[expense.BankID] {
+				duplicates = append(duplicates, expense)
+			} else {
+				bankIDs[expense.BankID] = true
+				added = append(added, expense)
+			}
+
+			continue
+		}
+
+		key := getExpenseKey(expense.Date, expense.Description, expense.Amount, expense.Kind)
+		if existing[key] > 0 {
+			existing[key]--
+			duplicates = append(duplicates, expense)
+		} else {
+			added = append(added, expense)
+		}
+	}
+
+	return added, duplicates
+}
+
+func getImportOptions(ctx *cli.Context) (importOptions, error) {
+	options := importOptions{
+		DateColumn:        ctx.String("date-column"),
+		DescriptionColumn: ctx.String("description-column"),
+		AmountColumn:      ctx.String("amount-column"),
+		CategoryColumn:    ctx.String("category-column"),
+		CurrencyColumn:    ctx.String("currency-column"),
+		DateFormat:        ctx.String("date-format"),
+		DecimalComma:      ctx.Bool("decimal-comma"),
+		Negate:            ctx.Bool("negate"),
+		Separator:         ',',
+		NoHeader:          ctx.Bool("no-header"),
+		SkipCredits:       ctx.Bool("skip-credits"),
+	}
+
+	if ctx.IsSet("separator") {
+		separator := ctx.String("separator")
+		if separator == `\t` {
+			separator = "\t"
+		}
+
+		r, size := utf8.DecodeRuneInString(separator)
+		if size == 0 || size != len(separator) || r == '"' || r == '\n' {
+			return importOptions{}, fmt.Errorf("'%s' can't be used as a separator!", separator)
+		}
+
+		options.Separator = r
+	}
+
+	return options, nil
+}
+
+// Returns the format of a file to import, as given by --format or else by
+// its extension
+func getImportFormat(ctx *cli.Context, path string) (string, error) {
+	format := strings.ToLower(ctx.String("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if !slices.Contains(importFormats, format) {
+			format = "csv"
+		}
+	}
+
+	if !slices.Contains(importFormats, format) {
+		return "", fmt.Errorf("'%s' is not a format that can be imported (must be one of %s)", format, strings.Join(importFormats, ", "))
+	}
+
+	return format, nil
+}
+
+func printImported(title string, imported []importedExpense) {
+	if len(imported) == 0 {
+		return
+	}
+
+	fmt.Println(title)
+	fmt.Println("Line  Date        Description              Category     Amount")
+	for _, expense := range imported {
+		fmt.Println(expense.String())
+	}
+}
+
+func HandleImport(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("Must provide the file to import!")
+	}
+
+	options, err := getImportOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := ctx.Args().Get(0)
+
+	format, err := getImportFormat(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	var imported []importedExpense
+	switch format {
+	case "ofx", "qfx":
+		imported, err = readOFX(file, options)
+	case "qif":
+		imported, err = readQIF(file, options)
+	default:
+		imported, err = readCSV(file, options)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Error reading '%s': %v", path, err)
+	}
+
+	skipped := []importedExpense{}
+	imported = slices.DeleteFunc(imported, func(expense importedExpense) bool {
+		if expense.Skip != "" {
+			skipped = append(skipped, expense)
+		}
+
+		return expense.Skip != ""
+	})
+
+	for idx, expense := range imported {
+		if expense.Category == "" {
+			imported[idx].Category = config.getRuleCategory(expense.Description)
+		}
+	}
+
+	added, duplicates := expenses.findDuplicates(imported)
+
+	if ctx.Bool("dry-run") {
+		printImported("Would add:", added)
+		printImported("Would skip, as they're already there:", duplicates)
+		printImported("Would skip:", skipped)
+
+		fmt.Printf("%d expenses would be added, %d skipped\n", len(added), len(duplicates)+len(skipped))
+		return nil
+	}
+
+	for _, expense := range added {
+		id := expenses.getFreeID()
+
+		e := createExpense(id, expense.Date, expense.Description, expense.Amount, expense.Category)
+		e.BankID = expense.BankID
+		e.Kind = expense.Kind
+
+		expenses.Expenses[id] = e
+	}
+
+	fmt.Printf("Imported %d expenses (%d already there were skipped)\n", len(added), len(duplicates))
+	if len(skipped) > 0 {
+		fmt.Printf("%d transactions were skipped, as they're neither expenses nor income (see --dry-run)\n", len(skipped))
+	}
+
+	return nil
+}
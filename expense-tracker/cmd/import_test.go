@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := "Data;Histórico;Valor;Categoria\n01/09/2026;Padaria;R$ 1.234,50;Food\n\n02/09/2026;Ônibus;4,40;\n"
+
+	imported, err := readCSV(strings.NewReader(data), importOptions{
+		DateColumn:        "data",
+		DescriptionColumn: "2",
+		AmountColumn:      "Valor",
+		CategoryColumn:    "Categoria",
+		DateFormat:        "02/01/2006",
+		DecimalComma:      true,
+		Separator:         ';',
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != 2 {
+		t.Fatalf("Expected 2 expenses, got %d", len(imported))
+	}
+
+	first := imported[0]
+	if first.Description != "Padaria" || first.Amount != usd(t, "1234.50") || first.Category != "food" {
+		t.Errorf("Unexpected first expense: %+v", first)
+	}
+
+	if !first.Date.Equal(time.Date(2026, time.September, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected the first expense on 2026-09-01, got %s", first.Date)
+	}
+
+	if imported[1].Amount != usd(t, "4.40") || imported[1].Line != 4 {
+		t.Errorf("Unexpected second expense: %+v", imported[1])
+	}
+
+	if _, err := readCSV(strings.NewReader("date,amount\n2026-09-01,10\n"), importOptions{DateFormat: DATE_FORMAT, Separator: ','}); err == nil {
+		t.Errorf("Expected an error for a file without descriptions")
+	}
+}
+
+func TestReadCSVRefunds(t *testing.T) {
+	data := "date,description,amount\n2026-09-01,Shoes,80.00\n2026-09-03,Shoes,-80.00\n"
+
+	imported, err := readCSV(strings.NewReader(data), importOptions{Separator: ','})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != 2 || imported[1].Amount != usd(t, "-80") {
+		t.Errorf("Expected the second expense to be a -$80 refund, got %+v", imported)
+	}
+
+	// Banks usually have purchases as negative amounts
+	imported, err = readCSV(strings.NewReader("Data;Histórico;Valor\n01/09/2026;Padaria;-3,50\n02/09/2026;Estorno;10,00\n"), importOptions{
+		DateColumn:        "data",
+		DescriptionColumn: "2",
+		AmountColumn:      "valor",
+		DateFormat:        "02/01/2006",
+		DecimalComma:      true,
+		Separator:         ';',
+		Negate:            true,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != 2 || imported[0].Amount != usd(t, "3.50") || imported[1].Amount != usd(t, "-10") {
+		t.Errorf("Expected a $3.50 expense and a -$10 refund, got %+v", imported)
+	}
+
+	if _, err := readCSV(strings.NewReader("date,description,amount\n2026-09-01,Nothing,0\n"), importOptions{Separator: ','}); err == nil {
+		t.Errorf("Expected an error for a zero amount")
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	day := time.Date(2026, time.September, 1, 12, 0, 0, 0, time.Local)
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: {CreatedAt: day, Description: "Coffee", Amount: usd(t, "3"), ID: 1},
+	}}
+
+	midnight := time.Date(2026, time.September, 1, 0, 0, 0, 0, time.Local)
+	imported := []importedExpense{
+		{Line: 2, Date: midnight, Description: "coffee ", Amount: usd(t, "3")},
+		{Line: 3, Date: midnight, Description: "Coffee", Amount: usd(t, "3")},
+		{Line: 4, Date: midnight, Description: "Coffee", Amount: usd(t, "3.50")},
+	}
+
+	added, duplicates := e.findDuplicates(imported)
+	if len(duplicates) != 1 || duplicates[0].Line != 2 {
+		t.Errorf("Expected line 2 to be a duplicate, got %+v", duplicates)
+	}
+
+	if len(added) != 2 || added[0].Line != 3 || added[1].Line != 4 {
+		t.Errorf("Expected lines 3 and 4 to be added, got %+v", added)
+	}
+}
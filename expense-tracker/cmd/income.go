@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Kinds of entries. Expenses are the default, so they're left out of the
+// database
+const (
+	KIND_EXPENSE = ""
+	KIND_INCOME  = "income"
+)
+
+func (e Expense) IsIncome() bool {
+	return e.Kind == KIND_INCOME
+}
+
+// Returns what an entry's amount does to the balance: income adds to it, and
+// expenses take away from it
+func (e Expense) signedAmount() Money {
+	if e.IsIncome() {
+		return e.Amount
+	}
+
+	return Money{Units: -e.Amount.Units, Currency: e.Amount.Currency}
+}
+
+// Parses a kind of entry, which is "expense" or "income"
+func parseKind(text string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "", "expense":
+		return KIND_EXPENSE, nil
+	case "income":
+		return KIND_INCOME, nil
+	default:
+		return "", fmt.Errorf("'%s' is not a type of entry (must be expense or income)", text)
+	}
+}
+
+// Returns the kind given by --type
+func getKind(ctx *cli.Context) (string, error) {
+	return parseKind(ctx.String("type"))
+}
+
+func (e *Expenses) hasIncome(period Period) bool {
+	for _, expense := range e.Expenses {
+		if expense.IsIncome() && period.Contains(expense.CreatedAt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Money in and out in a month, in the base currency
+type MonthBalance struct {
+	Month    YearMonth
+	Income   Money
+	Expenses Money
+}
+
+func (b MonthBalance) Net() Money {
+	return b.Income.Sub(b.Expenses)
+}
+
+// Sums up income and expenses in a period, per month (oldest first). Months
+// without any entries are left out
+func (e *Expenses) getMonthlyBalances(period Period) ([]MonthBalance, error) {
+	base := config.GetBaseCurrency()
+	balances := []MonthBalance{}
+
+	for _, id := range e.getExpenseIDsByDate() {
+		expense := e.Expenses[id]
+		if !period.Contains(expense.CreatedAt) {
+			continue
+		}
+
+		amount, err := toBase(expense.Amount, expense.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Can't convert entry %d: %v", id, err)
+		}
+
+		ym := getYearMonth(expense.CreatedAt)
+		if len(balances) == 0 || balances[len(balances)-1].Month != ym {
+			balances = append(balances, MonthBalance{
+				Month:    ym,
+				Income:   Money{Currency: base},
+				Expenses: Money{Currency: base},
+			})
+		}
+
+		b := &balances[len(balances)-1]
+		if expense.IsIncome() {
+			b.Income = b.Income.Add(amount)
+		} else {
+			b.Expenses = b.Expenses.Add(amount)
+		}
+	}
+
+	return balances, nil
+}
+
+func printMonthlyBalances(period Period) error {
+	balances, err := expenses.getMonthlyBalances(period)
+	if err != nil {
+		return err
+	}
+
+	if len(balances) == 0 {
+		fmt.Println("There are no entries to summarize!")
+		return nil
+	}
+
+	base := config.GetBaseCurrency()
+	total := MonthBalance{Income: Money{Currency: base}, Expenses: Money{Currency: base}}
+
+	fmt.Println("Month     Income         Expenses       Net")
+	for _, b := range balances {
+		fmt.Printf("%-9s %-14s %-14s %s\n", b.Month, b.Income, b.Expenses, b.Net())
+
+		total.Income = total.Income.Add(b.Income)
+		total.Expenses = total.Expenses.Add(b.Expenses)
+	}
+
+	fmt.Printf("%-9s %-14s %-14s %s\n", "Total", total.Income, total.Expenses, total.Net())
+	return nil
+}
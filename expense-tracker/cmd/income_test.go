@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func incomeAt(id uint64, at time.Time, amount Money) Expense {
+	expense := expenseAt(id, at, amount)
+	expense.Kind = KIND_INCOME
+	return expense
+}
+
+func TestMonthlyBalances(t *testing.T) {
+	useExpenses(t, &Expenses{})
+
+	september := time.Date(2026, time.September, 5, 12, 0, 0, 0, time.Local)
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: incomeAt(1, september, usd(t, "3000")),
+		2: expenseAt(2, september.AddDate(0, 0, 1), usd(t, "200")),
+		3: expenseAt(3, september.AddDate(0, 0, 2), usd(t, "-50")),
+		4: expenseAt(4, september.AddDate(0, 1, 0), usd(t, "100")),
+	}}
+
+	balances, err := e.getMonthlyBalances(Period{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []MonthBalance{
+		{YearMonth{2026, time.September}, usd(t, "3000"), usd(t, "150")},
+		{YearMonth{2026, time.October}, usd(t, "0"), usd(t, "100")},
+	}
+
+	if !slices.Equal(balances, expected) {
+		t.Fatalf("Got %v, expected %v", balances, expected)
+	}
+
+	if balances[0].Net() != usd(t, "2850") || balances[1].Net() != usd(t, "-100") {
+		t.Errorf("Got nets of %s and %s, expected $2850.00 and -$100.00", balances[0].Net(), balances[1].Net())
+	}
+}
+
+func TestRunningBalance(t *testing.T) {
+	useExpenses(t, &Expenses{})
+
+	day := time.Date(2026, time.September, 1, 12, 0, 0, 0, time.Local)
+	euros, err := ParseMoney("10", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: expenseAt(1, day, usd(t, "10")),
+		2: incomeAt(2, day.AddDate(0, 0, 1), usd(t, "3000")),
+		3: expenseAt(3, day.AddDate(0, 0, 2), usd(t, "-5")),
+		4: expenseAt(4, day.AddDate(0, 0, 3), euros),
+		5: expenseAt(5, day.AddDate(0, 0, 4), usd(t, "1")),
+	}}
+
+	// There's no rate for euros, so the balance stops there
+	rates = &Rates{Path: RATES_NAME}
+	t.Cleanup(func() { rates = nil })
+
+	balances, err := e.getRunningBalances()
+	if err == nil {
+		t.Errorf("Expected an error for an entry that can't be converted")
+	}
+
+	expected := map[uint64]Money{1: usd(t, "-10"), 2: usd(t, "2990"), 3: usd(t, "2995")}
+	if !maps.Equal(balances, expected) {
+		t.Errorf("Got balances %v, expected %v", balances, expected)
+	}
+}
+
+func TestFilteredListBalance(t *testing.T) {
+	september := time.Date(2026, time.September, 20, 12, 0, 0, 0, time.Local)
+	october := september.AddDate(0, 0, 15)
+
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: expenseAt(1, september, usd(t, "100")),
+		2: incomeAt(2, october, usd(t, "50")),
+		3: categorized(3, october.AddDate(0, 0, 1), usd(t, "12"), "food"),
+	}}
+
+	useExpenses(t, e)
+
+	food := "food"
+	out := captureOutput(t, func() {
+		if err := HandleList(newContext(t, map[string]string{"from": "2026-10-01"})); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The balance goes on from what was spent before October
+	if !strings.Contains(out, "-$50.00") || !strings.Contains(out, "-$62.00") {
+		t.Errorf("Expected balances of -$50.00 and -$62.00:\n%s", out)
+	}
+
+	balances, err := e.getRunningBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range e.getListedIDs(listFilter{Category: &food}) {
+		if balances[id] != usd(t, "-62") {
+			t.Errorf("Entry %d has a balance of %s, expected -$62.00", id, balances[id])
+		}
+	}
+}
+
+func TestLimitsIgnoreIncome(t *testing.T) {
+	useExpenses(t, &Expenses{})
+
+	day := time.Date(2026, time.September, 5, 12, 0, 0, 0, time.Local)
+	ym := getYearMonth(day)
+
+	salary := incomeAt(2, day, usd(t, "3000"))
+	salary.Category = "food"
+
+	e := &Expenses{
+		Expenses: map[uint64]Expense{
+			1: categorized(1, day, usd(t, "60"), "food"),
+			2: salary,
+		},
+		Limits:         Limits{Default: usd(t, "100")},
+		CategoryLimits: map[string]Limits{"food": {Default: usd(t, "100")}},
+	}
+
+	if over, ok, err := e.getAmountOverLimit(ym); err != nil || ok {
+		t.Errorf("Income went towards the limit: %s over (%v)", over, err)
+	}
+
+	if over, ok, err := e.getAmountOverCategoryLimit(ym, "food"); err != nil || ok {
+		t.Errorf("Income went towards the category limit: %s over (%v)", over, err)
+	}
+}
+
+func TestForecastIgnoresIncome(t *testing.T) {
+	useExpenses(t, &Expenses{})
+
+	date := func(day int) time.Time {
+		return time.Date(2026, time.September, day, 0, 0, 0, 0, time.Local)
+	}
+
+	e := &Expenses{
+		Expenses: map[uint64]Expense{
+			1: expenseAt(1, date(2), usd(t, "100")),
+			2: incomeAt(2, date(5), usd(t, "3000")),
+		},
+		Limits: Limits{Default: usd(t, "1000")},
+		Recurring: map[uint64]Recurring{
+			1: {ID: 1, Description: "Salary", Amount: usd(t, "3000"), Kind: KIND_INCOME, Every: EVERY_MONTH, Day: 25, Next: date(25)},
+		},
+	}
+
+	f, err := e.getForecast(YearMonth{2026, time.September}, "", date(10).Add(12*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Spent != usd(t, "100") || !f.Recurring.IsZero() || f.Projected != usd(t, "300") {
+		t.Errorf("Expected $100 spent, nothing still due and $300 projected, got %+v", f)
+	}
+}
+
+func TestSaveAndImport(t *testing.T) {
+	useExpenses(t, &Expenses{})
+
+	day := time.Date(2026, time.September, 1, 0, 0, 0, 0, time.Local)
+
+	refund := categorized(3, day, usd(t, "-20"), "clothes")
+	refund.BankID = "123:T2"
+
+	// An income and an expense alike in everything else
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: incomeAt(1, day, usd(t, "3000")),
+		2: expenseAt(2, day, usd(t, "3000")),
+		3: refund,
+	}}
+
+	buf := &bytes.Buffer{}
+	if err := e.writeCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := readCSV(buf, importOptions{Separator: ','})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != len(e.Expenses) {
+		t.Fatalf("Expected %d entries, got %d", len(e.Expenses), len(imported))
+	}
+
+	for idx, got := range imported {
+		expected := e.Expenses[uint64(idx+1)]
+		if !got.Date.Equal(expected.CreatedAt) || got.Description != expected.Description || got.Amount != expected.Amount ||
+			got.Category != expected.Category || got.Kind != expected.Kind || got.BankID != expected.BankID {
+			t.Errorf("Entry %d came back as %+v, expected %+v", idx+1, got, expected)
+		}
+	}
+
+	added, duplicates := (&Expenses{}).findDuplicates(imported)
+	if len(added) != 3 || len(duplicates) != 0 {
+		t.Errorf("Expected everything to be added to an empty database, got %d added", len(added))
+	}
+
+	added, duplicates = e.findDuplicates(imported)
+	if len(added) != 0 || len(duplicates) != 3 {
+		t.Errorf("Expected everything to be a duplicate of itself, got %d added", len(added))
+	}
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	DEFAULT_CURRENCY = "USD"
+)
+
+// Digits after the decimal point, for currencies that don't use 2
+var currencyDecimals map[string]int = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"CLP": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+func getDecimals(currency string) int {
+	if decimals, ok := currencyDecimals[currency]; ok {
+		return decimals
+	}
+
+	return 2
+}
+
+// An exact amount of money, in the minor units of its currency (cents for
+// dollars), so that sums never drift like floats do
+type Money struct {
+	Units    int64  `json:"units"`
+	Currency string `json:"currency"`
+}
+
+// Parses a decimal amount like "12.5" or "-3.99", without going through
+// floats. Amounts with more decimals than the currency has are refused
+func ParseMoney(amount string, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !isCurrency(currency) {
+		return Money{}, fmt.Errorf("'%s' is not a valid currency (must be a code like USD or EUR)", currency)
+	}
+
+	decimals := getDecimals(currency)
+
+	text := strings.TrimSpace(amount)
+
+	negative := false
+	if rest, ok := strings.CutPrefix(text, "-"); ok {
+		negative, text = true, rest
+	} else {
+		text = strings.TrimPrefix(text, "+")
+	}
+
+	whole, frac, hasFrac := strings.Cut(text, ".")
+	if whole == "" && !hasFrac {
+		return Money{}, fmt.Errorf("'%s' is not a valid amount!", amount)
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	if !isDigits(whole) || (hasFrac && (frac == "" || !isDigits(frac))) {
+		return Money{}, fmt.Errorf("'%s' is not a valid amount!", amount)
+	}
+
+	if len(frac) > decimals {
+		// Trailing zeroes don't change the amount
+		if strings.Trim(frac[decimals:], "0") != "" {
+			return Money{}, fmt.Errorf("'%s' has too many decimals for %s (at most %d)", amount, currency, decimals)
+		}
+
+		frac = frac[:decimals]
+	}
+
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	units, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("'%s' is not a valid amount!", amount)
+	}
+
+	if negative {
+		units = -units
+	}
+
+	return Money{Units: units, Currency: currency}, nil
+}
+
+// Currencies go by their three letter ISO 4217 codes
+func isCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return text != ""
+}
+
+// Returns the amount as a plain decimal, like "12.50"
+func (m Money) Decimal() string {
+	decimals := getDecimals(m.Currency)
+
+	units := m.Units
+	sign := ""
+	if units < 0 {
+		sign, units = "-", -units
+	}
+
+	digits := strconv.FormatInt(units, 10)
+	if decimals == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+}
+
+func (m Money) String() string {
+	if m.Currency == "USD" {
+		if m.Units < 0 {
+			return "-$" + Money{Units: -m.Units, Currency: m.Currency}.Decimal()
+		}
+
+		return "$" + m.Decimal()
+	}
+
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Units == 0
+}
+
+// Returns the currency two amounts share. The zero Money has no currency,
+// and goes with any other
+//
+// Amounts in different currencies can't be added up or compared, so callers
+// convert them into the base currency first (see toBase). Mixing them anyway
+// is a bug, and panics rather than giving a wrong total
+func (m Money) mustMatch(other Money) string {
+	switch {
+	case m.Currency == "":
+		return other.Currency
+	case other.Currency == "" || m.Currency == other.Currency:
+		return m.Currency
+	default:
+		panic(fmt.Sprintf("Can't mix %s and %s amounts", m.Currency, other.Currency))
+	}
+}
+
+// Adds two amounts. They must be in the same currency (mixing them is a bug)
+func (m Money) Add(other Money) Money {
+	return Money{Units: m.Units + other.Units, Currency: m.mustMatch(other)}
+}
+
+// Subtracts an amount. They must be in the same currency
+func (m Money) Sub(other Money) Money {
+	return Money{Units: m.Units - other.Units, Currency: m.mustMatch(other)}
+}
+
+// Compares two amounts in the same currency, like cmp.Compare
+func (m Money) Cmp(other Money) int {
+	m.mustMatch(other)
+
+	switch {
+	case m.Units < other.Units:
+		return -1
+	case m.Units > other.Units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Converts an amount into another currency, at some rate (how much of it one
+// of this currency is worth). It's rounded to the nearest minor unit, with
+// halves going away from zero
+func (m Money) Convert(to string, rate *big.Rat) Money {
+	value := new(big.Rat).SetInt64(m.Units)
+	value.Mul(value, rate)
+
+	shift := getDecimals(to) - getDecimals(m.Currency)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(shift, -shift))), nil))
+	if shift >= 0 {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	quo, rem := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+
+	// Rounding is decided by whether the remainder is at least half the way
+	if rem.Sign() != 0 && new(big.Int).Abs(new(big.Int).Mul(rem, big.NewInt(2))).Cmp(value.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(rem.Sign())))
+	}
+
+	return Money{Units: quo.Int64(), Currency: to}
+}
+
+// Multiplies an amount by a fraction, rounding like Convert does
+func (m Money) Scale(num, den int64) Money {
+	return m.Convert(m.Currency, big.NewRat(num, den))
+}
+
+// Reads money from JSON. Besides its own format, it takes the plain numbers
+// older databases stored amounts as, in the default currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+
+	if strings.HasPrefix(text, "{") {
+		type money Money
+		return json.Unmarshal(data, (*money)(m))
+	}
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	parsed, err := ParseMoney(text, DEFAULT_CURRENCY)
+	if err != nil {
+		// Limits used to be floats, which can have stray decimals
+		f, floatErr := strconv.ParseFloat(text, 64)
+		if floatErr != nil {
+			return err
+		}
+
+		parsed = Money{Units: int64(math.Round(f * 100)), Currency: DEFAULT_CURRENCY}
+	}
+
+	*m = parsed
+	return nil
+}
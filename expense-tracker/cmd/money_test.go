@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		expected Money
+	}{
+		{"12.34", "USD", Money{1234, "USD"}},
+		{"12.5", "usd", Money{1250, "USD"}},
+		{"12", "USD", Money{1200, "USD"}},
+		{".5", "USD", Money{50, "USD"}},
+		{"+3", "EUR", Money{300, "EUR"}},
+		{"-3.99", "USD", Money{-399, "USD"}},
+		{" 7.10 ", "USD", Money{710, "USD"}},
+		{"1.2300", "USD", Money{123, "USD"}},
+		{"1500", "JPY", Money{1500, "JPY"}},
+		{"1.234", "KWD", Money{1234, "KWD"}},
+		{"92233720368547758.07", "USD", Money{9223372036854775807, "USD"}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMoney(test.amount, test.currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s): %v", test.amount, test.currency, err)
+			continue
+		}
+
+		if got != test.expected {
+			t.Errorf("ParseMoney(%q, %s) = %+v, expected %+v", test.amount, test.currency, got, test.expected)
+		}
+	}
+
+	invalid := []struct {
+		amount   string
+		currency string
+	}{
+		{"12.345", "USD"},
+		{"1.5", "JPY"},
+		{"1,234.50", "USD"},
+		{"12,50", "EUR"},
+		{"1e3", "USD"},
+		{"12.", "USD"},
+		{"--1", "USD"},
+		{"", "USD"},
+		{"abc", "USD"},
+		{"92233720368547758.08", "USD"},
+		{"12", "US"},
+		{"12", "U$D"},
+	}
+
+	for _, test := range invalid {
+		if got, err := ParseMoney(test.amount, test.currency); err == nil {
+			t.Errorf("ParseMoney(%q, %s) = %+v, expected an error", test.amount, test.currency, got)
+		}
+	}
+}
+
+func TestMoneyFormatting(t *testing.T) {
+	tests := []struct {
+		money   Money
+		decimal string
+		text    string
+	}{
+		{Money{1234, "USD"}, "12.34", "$12.34"},
+		{Money{5, "USD"}, "0.05", "$0.05"},
+		{Money{0, "USD"}, "0.00", "$0.00"},
+		{Money{-1050, "USD"}, "-10.50", "-$10.50"},
+		{Money{-5, "EUR"}, "-0.05", "-0.05 EUR"},
+		{Money{1500, "JPY"}, "1500", "1500 JPY"},
+		{Money{1234, "KWD"}, "1.234", "1.234 KWD"},
+	}
+
+	for _, test := range tests {
+		if got := test.money.Decimal(); got != test.decimal {
+			t.Errorf("%+v.Decimal() = %q, expected %q", test.money, got, test.decimal)
+		}
+
+		if got := test.money.String(); got != test.text {
+			t.Errorf("%+v.String() = %q, expected %q", test.money, got, test.text)
+		}
+
+		// What's written can be read back
+		if parsed, err := ParseMoney(test.decimal, test.money.Currency); err != nil || parsed != test.money {
+			t.Errorf("ParseMoney(%q) = %+v (%v), expected %+v", test.decimal, parsed, err, test.money)
+		}
+	}
+}
+
+func TestMoneyRounding(t *testing.T) {
+	tests := []struct {
+		money    Money
+		rate     string
+		expected int64
+	}{
+		{Money{5, "USD"}, "1/2", 3},
+		{Money{-5, "USD"}, "1/2", -3},
+		{Money{7, "USD"}, "1/3", 2},
+		{Money{-7, "USD"}, "1/3", -2},
+		{Money{1000, "USD"}, "1/3", 333},
+		{Money{1000, "USD"}, "2/3", 667},
+	}
+
+	for _, test := range tests {
+		rate, _ := new(big.Rat).SetString(test.rate)
+		if got := test.money.Convert(test.money.Currency, rate); got.Units != test.expected {
+			t.Errorf("%+v at %s = %d units, expected %d", test.money, test.rate, got.Units, test.expected)
+		}
+	}
+
+	if got := (Money{1000, "USD"}).Scale(2, 3); got != (Money{667, "USD"}) {
+		t.Errorf("$10.00 scaled by 2/3 = %s, expected $6.67", got)
+	}
+}
+
+func TestMixingCurrencies(t *testing.T) {
+	usd, eur := Money{100, "USD"}, Money{100, "EUR"}
+
+	// The zero Money goes with anything
+	if got := (Money{}).Add(eur); got != eur {
+		t.Errorf("Adding to the zero Money = %+v, expected %+v", got, eur)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Adding USD and EUR amounts didn't panic")
+		}
+	}()
+
+	usd.Add(eur)
+}
+
+func TestLegacyMoney(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected Money
+	}{
+		{`12.5`, Money{1250, "USD"}},
+		{`12.50`, Money{1250, "USD"}},
+		{`"12.50"`, Money{1250, "USD"}},
+		{`0.30000000000000004`, Money{30, "USD"}},
+		{`1e2`, Money{10000, "USD"}},
+		{`{"units": 1250, "currency": "EUR"}`, Money{1250, "EUR"}},
+	}
+
+	for _, test := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(test.json), &m); err != nil {
+			t.Errorf("Unmarshal(%s): %v", test.json, err)
+			continue
+		}
+
+		if m != test.expected {
+			t.Errorf("Unmarshal(%s) = %+v, expected %+v", test.json, m, test.expected)
+		}
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`"lots"`), &m); err == nil {
+		t.Errorf("Unmarshal(\"lots\") = %+v, expected an error", m)
+	}
+}
+
+// Databases from before amounts were exact stored them as JSON numbers, and
+// limits as an array of floats
+func TestLegacyDatabase(t *testing.T) {
+	old := `{
+		"expenses": {
+			"1": {"created_at": "2026-09-01T12:00:00Z", "description": "Lunch", "Amount": 12.50, "id": 1},
+			"2": {"created_at": "2026-09-02T12:00:00Z", "description": "Coffee", "Amount": 0.1, "id": 2},
+			"3": {"created_at": "2026-09-03T12:00:00Z", "description": "Rent", "Amount": 1234.56, "id": 3}
+		},
+		"limits": [0, 0, 0, 0, 0, 0, 0, 0, 250.5, 0, 0, 0]
+	}`
+
+	migrated := &Expenses{}
+	if err := json.Unmarshal([]byte(old), migrated); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[uint64]Money{1: {1250, "USD"}, 2: {10, "USD"}, 3: {123456, "USD"}}
+	for id, amount := range expected {
+		if got := migrated.Expenses[id].Amount; got != amount {
+			t.Errorf("Expense %d: got %+v, expected %+v", id, got, amount)
+		}
+	}
+
+	september := YearMonth{time.Now().Year(), time.September}
+	if got := migrated.Limits.For(september); got != (Money{25050, "USD"}) {
+		t.Errorf("Got a limit of %+v for September, expected $250.50", got)
+	}
+
+	// Saving in the new format and loading it again changes nothing
+	data, err := json.Marshal(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := &Expenses{}
+	if err := json.Unmarshal(data, reloaded); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, amount := range expected {
+		if got := reloaded.Expenses[id].Amount; got != amount {
+			t.Errorf("Expense %d after saving: got %+v, expected %+v", id, got, amount)
+		}
+	}
+
+	if got := reloaded.Limits.For(september); got != (Money{25050, "USD"}) {
+		t.Errorf("Got a limit of %+v for September after saving, expected $250.50", got)
+	}
+
+	if got := reloaded.Limits.For(YearMonth{time.Now().Year(), time.August}); !got.IsZero() {
+		t.Errorf("Got a limit of %+v for August, expected none", got)
+	}
+}
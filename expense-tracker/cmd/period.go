@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	DATE_FORMAT       = "2006-01-02"
+	YEAR_MONTH_FORMAT = "2006-01"
+)
+
+// A month of a specific year, like September 2026
+type YearMonth struct {
+	Year  int
+	Month time.Month
+}
+
+func getYearMonth(t time.Time) YearMonth {
+	t = t.In(time.Local)
+	return YearMonth{t.Year(), t.Month()}
+}
+
+// Parses a month like "2026-09". A bare month number, like "9", is taken to
+// be in the current year
+func ParseYearMonth(text string) (YearMonth, error) {
+	text = strings.TrimSpace(text)
+
+	if month, err := strconv.Atoi(text); err == nil {
+		if month < 1 || month > 12 {
+			return YearMonth{}, fmt.Errorf("'%d' is not a valid month (must be range from 1-12)", month)
+		}
+
+		return YearMonth{time.Now().Year(), time.Month(month)}, nil
+	}
+
+	t, err := time.Parse(YEAR_MONTH_FORMAT, text)
+	if err != nil {
+		return YearMonth{}, fmt.Errorf("'%s' is not a valid month (must be like 2026-09)", text)
+	}
+
+	return YearMonth{t.Year(), t.Month()}, nil
+}
+
+func (ym YearMonth) String() string {
+	return fmt.Sprintf("%04d-%02d", ym.Year, ym.Month)
+}
+
+// Months are written as "2026-09", so they can be used as JSON keys
+func (ym YearMonth) MarshalText() ([]byte, error) {
+	return []byte(ym.String()), nil
+}
+
+func (ym *YearMonth) UnmarshalText(text []byte) error {
+	t, err := time.Parse(YEAR_MONTH_FORMAT, string(text))
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid month (must be like 2026-09)", text)
+	}
+
+	*ym = YearMonth{t.Year(), t.Month()}
+	return nil
+}
+
+// A span of time, from the start of From up to (but not including) To. A zero
+// bound leaves that side open
+type Period struct {
+	From time.Time
+	To   time.Time
+	Name string
+}
+
+func monthPeriod(ym YearMonth) Period {
+	from := time.Date(ym.Year, ym.Month, 1, 0, 0, 0, 0, time.Local)
+	return Period{From: from, To: from.AddDate(0, 1, 0), Name: fmt.Sprintf("in %s %d", ym.Month, ym.Year)}
+}
+
+func yearPeriod(year int) Period {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	return Period{From: from, To: from.AddDate(1, 0, 0), Name: fmt.Sprintf("in %d", year)}
+}
+
+func (p Period) Contains(t time.Time) bool {
+	return (p.From.IsZero() || !t.Before(p.From)) && (p.To.IsZero() || t.Before(p.To))
+}
+
+func (p Period) IsZero() bool {
+	return p.From.IsZero() && p.To.IsZero()
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Parses a date like "2026-09-30", or a relative one: "today", "yesterday",
+// "3 days ago" or a weekday like "friday" (the last one, counting today)
+func parseDate(text string, now time.Time) (time.Time, error) {
+	day := strings.ToLower(strings.TrimSpace(text))
+	today := startOfDay(now)
+
+	switch day {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if n, ok := strings.CutSuffix(day, " days ago"); ok {
+		if days, err := strconv.Atoi(strings.TrimSpace(n)); err == nil && days >= 0 {
+			return today.AddDate(0, 0, -days), nil
+		}
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if day == strings.ToLower(weekday.String()) {
+			back := (int(today.Weekday()) - int(weekday) + 7) % 7
+			return today.AddDate(0, 0, -back), nil
+		}
+	}
+
+	t, err := time.ParseInLocation(DATE_FORMAT, day, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is not a valid date (must be like 2026-09-30, yesterday or friday)", text)
+	}
+
+	return t, nil
+}
+
+// Returns the period given by --month, --year or --from/--to. Without any of
+// them, the period covers all time
+func getPeriod(ctx *cli.Context) (Period, error) {
+	given := 0
+	for _, flag := range []string{"month", "year"} {
+		if ctx.IsSet(flag) {
+			given++
+		}
+	}
+
+	if ctx.IsSet("from") || ctx.IsSet("to") {
+		given++
+	}
+
+	if given > 1 {
+		return Period{}, errors.New("Only one of --month, --year or --from/--to can be given!")
+	}
+
+	switch {
+	case ctx.IsSet("month"):
+		ym, err := ParseYearMonth(ctx.String("month"))
+		if err != nil {
+			return Period{}, err
+		}
+
+		return monthPeriod(ym), nil
+	case ctx.IsSet("year"):
+		year := ctx.Int("year")
+		if year < 1 || year > 9999 {
+			return Period{}, fmt.Errorf("'%d' is not a valid year!", year)
+		}
+
+		return yearPeriod(year), nil
+	}
+
+	period := Period{}
+	names := []string{}
+
+	if ctx.IsSet("from") {
+		from, err := parseDate(ctx.String("from"), time.Now())
+		if err != nil {
+			return Period{}, err
+		}
+
+		period.From = from
+		names = append(names, "from "+from.Format(DATE_FORMAT))
+	}
+
+	if ctx.IsSet("to") {
+		to, err := parseDate(ctx.String("to"), time.Now())
+		if err != nil {
+			return Period{}, err
+		}
+
+		// The last day counts too
+		period.To = to.AddDate(0, 0, 1)
+		names = append(names, "to "+to.Format(DATE_FORMAT))
+	}
+
+	if !period.From.IsZero() && !period.To.IsZero() && !period.From.Before(period.To) {
+		return Period{}, errors.New("--from must come before --to!")
+	}
+
+	period.Name = strings.Join(names, " ")
+	return period, nil
+}
+
+// Spending limits, for every month or for specific ones
+type Limits struct {
+	// Applies to months without a limit of their own (zero for no limit)
+	Default Money               `json:"default"`
+	Months  map[YearMonth]Money `json:"months,omitempty"`
+}
+
+// Returns the limit for a month (zero if there's none)
+func (l Limits) For(ym YearMonth) Money {
+	if limit, ok := l.Months[ym]; ok {
+		return limit
+	}
+
+	return l.Default
+}
+
+// Sets the limit for a month
+func (l *Limits) Set(ym YearMonth, limit Money) {
+	if l.Months == nil {
+		l.Months = map[YearMonth]Money{}
+	}
+
+	l.Months[ym] = limit
+}
+
+// Reads limits from JSON. Besides its own format, it takes the 12 month array
+// older databases stored, which is taken to be for the current year
+func (l *Limits) UnmarshalJSON(data []byte) error {
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		type limits Limits
+		return json.Unmarshal(data, (*limits)(l))
+	}
+
+	months := [12]Money{}
+	if err := json.Unmarshal(data, &months); err != nil {
+		return err
+	}
+
+	*l = Limits{}
+
+	// Setting a limit for every month was the same as setting a default one
+	same := true
+	for _, limit := range months {
+		same = same && limit == months[0]
+	}
+
+	if same {
+		l.Default = months[0]
+		return nil
+	}
+
+	year := time.Now().Year()
+	for idx, limit := range months {
+		if !limit.IsZero() {
+			l.Set(YearMonth{year, time.Month(idx + 1)}, limit)
+		}
+	}
+
+	return nil
+}
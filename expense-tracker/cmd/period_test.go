@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func usd(t *testing.T, amount string) Money {
+	t.Helper()
+
+	m, err := ParseMoney(amount, DEFAULT_CURRENCY)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func expenseAt(id uint64, at time.Time, amount Money) Expense {
+	return Expense{CreatedAt: at, Description: "test", Amount: amount, ID: id}
+}
+
+func TestLimitInDecember(t *testing.T) {
+	e := &Expenses{Expenses: map[uint64]Expense{
+		1: expenseAt(1, time.Date(2026, time.December, 31, 23, 0, 0, 0, time.Local), usd(t, "60")),
+		2: expenseAt(2, time.Date(2026, time.December, 1, 0, 0, 0, 0, time.Local), usd(t, "50")),
+
+		// Same month, other years
+		3: expenseAt(3, time.Date(2025, time.December, 15, 12, 0, 0, 0, time.Local), usd(t, "500")),
+		4: expenseAt(4, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.Local), usd(t, "500")),
+	}}
+
+	december := YearMonth{2026, time.December}
+	e.Limits.Set(december, usd(t, "100"))
+
+	over, ok, err := e.getAmountOverLimit(december)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || over != usd(t, "10") {
+		t.Errorf("Expected December to be over by $10.00, got %s (%v)", over, ok)
+	}
+
+	// November has no limit of its own, nor a default one
+	if _, ok, _ := e.getAmountOverLimit(YearMonth{2026, time.November}); ok {
+		t.Errorf("Expected November to have no limit")
+	}
+
+	e.Limits.Default = usd(t, "500")
+	over, ok, err = e.getAmountOverLimit(YearMonth{2025, time.December})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || !over.IsZero() {
+		t.Errorf("Expected December 2025 to be exactly at the default limit, got %s (%v)", over, ok)
+	}
+
+	if total, err := e.getSummary(yearPeriod(2026)); err != nil || total != usd(t, "110") {
+		t.Errorf("Expected 2026 to total $110.00, got %s", total)
+	}
+}
+
+func TestLegacyLimits(t *testing.T) {
+	limits := Limits{}
+	if err := json.Unmarshal([]byte(`[0,0,0,0,0,0,0,0,0,0,0,1500]`), &limits); err != nil {
+		t.Fatal(err)
+	}
+
+	// Limits for a month were stored one place before it
+	december := YearMonth{time.Now().Year(), time.December}
+	if limit := limits.For(december); limit != usd(t, "1500") {
+		t.Errorf("Expected a $1500.00 limit for December, got %s", limit)
+	}
+
+	if !limits.Default.IsZero() {
+		t.Errorf("Expected no default limit, got %s", limits.Default)
+	}
+
+	if err := json.Unmarshal([]byte(`[90,90,90,90,90,90,90,90,90,90,90,90]`), &limits); err != nil {
+		t.Fatal(err)
+	}
+
+	if limits.Default != usd(t, "90") || len(limits.Months) != 0 {
+		t.Errorf("Expected a $90.00 default limit, got %+v", limits)
+	}
+}
+
+func TestParseYearMonth(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected YearMonth
+		ok       bool
+	}{
+		{"2026-09", YearMonth{2026, time.September}, true},
+		{"2026-12", YearMonth{2026, time.December}, true},
+		{"12", YearMonth{time.Now().Year(), time.December}, true},
+		{"13", YearMonth{}, false},
+		{"2026-13", YearMonth{}, false},
+		{"september", YearMonth{}, false},
+	}
+
+	for _, test := range tests {
+		ym, err := ParseYearMonth(test.text)
+		if (err == nil) != test.ok || ym != test.expected {
+			t.Errorf("ParseYearMonth(%q) = %v, %v", test.text, ym, err)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2026, time.September, 30, 15, 0, 0, 0, time.Local)
+	day := func(d int) time.Time {
+		return time.Date(2026, time.September, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		text     string
+		expected time.Time
+	}{
+		{"2026-09-01", day(1)},
+		{"today", day(30)},
+		{"Yesterday", day(29)},
+		{"3 days ago", day(27)},
+		{"friday", day(25)},
+		{"wednesday", day(30)},
+	}
+
+	for _, test := range tests {
+		date, err := parseDate(test.text, now)
+		if err != nil || !date.Equal(test.expected) {
+			t.Errorf("parseDate(%q) = %v, %v (expected %v)", test.text, date, err, test.expected)
+		}
+	}
+
+	if _, err := parseDate("last week", now); err == nil {
+		t.Errorf("Expected 'last week' to not be a valid date")
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	RATES_NAME = "rates.csv"
+)
+
+// What a currency was worth in another one, from some day on
+type Rate struct {
+	Date time.Time
+	Rate *big.Rat
+}
+
+type currencyPair struct {
+	from, to string
+}
+
+// Dated exchange rates, read from a CSV file with lines like
+//
+//	2026-09-01,EUR,USD,1.0842
+//
+// meaning that, from that day on, 1 EUR was worth 1.0842 USD
+type Rates struct {
+	Path  string
+	rates map[currencyPair][]Rate
+}
+
+func LoadRates(path string) (*Rates, error) {
+	rates := &Rates{Path: path, rates: map[currencyPair][]Rate{}}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return rates, nil
+		}
+
+		return nil, err
+	}
+
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Error reading exchange rates: %v", err)
+		}
+
+		// The header is optional
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		date, err := time.ParseInLocation(DATE_FORMAT, record[0], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading exchange rates: '%s' (line %d) is not a valid date!", record[0], line)
+		}
+
+		from, to := strings.ToUpper(record[1]), strings.ToUpper(record[2])
+		if !isCurrency(from) || !isCurrency(to) {
+			return nil, fmt.Errorf("Error reading exchange rates: line %d must have two currency codes, like EUR,USD", line)
+		}
+
+		rate, ok := new(big.Rat).SetString(record[3])
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("Error reading exchange rates: '%s' (line %d) is not a valid rate!", record[3], line)
+		}
+
+		pair := currencyPair{from, to}
+		rates.rates[pair] = append(rates.rates[pair], Rate{date, rate})
+	}
+
+	for _, list := range rates.rates {
+		slices.SortStableFunc(list, func(a, b Rate) int {
+			return a.Date.Compare(b.Date)
+		})
+	}
+
+	return rates, nil
+}
+
+// Returns the latest rate for a pair on or before some day
+func (r *Rates) find(pair currencyPair, day time.Time) (Rate, bool) {
+	list := r.rates[pair]
+
+	idx, _ := slices.BinarySearchFunc(list, day, func(rate Rate, day time.Time) int {
+		if rate.Date.After(day) {
+			return 1
+		}
+
+		return -1
+	})
+
+	if idx == 0 {
+		return Rate{}, false
+	}
+
+	return list[idx-1], true
+}
+
+// Returns the rate to convert from a currency to another on some date. Rates
+// given the other way around are used too, inverted
+func (r *Rates) Get(from, to string, date time.Time) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	day := startOfDay(date)
+
+	if rate, ok := r.find(currencyPair{from, to}, day); ok {
+		return rate.Rate, nil
+	}
+
+	if rate, ok := r.find(currencyPair{to, from}, day); ok {
+		return new(big.Rat).Inv(rate.Rate), nil
+	}
+
+	return nil, fmt.Errorf(
+		"No exchange rate from %s to %s on or before %s! Add one to '%s', like: %s,%s,%s,<rate>",
+		from, to, day.Format(DATE_FORMAT), r.Path, day.Format(DATE_FORMAT), from, to,
+	)
+}
+
+// Converts an amount into another currency, at its rate on some date
+func (r *Rates) Convert(m Money, to string, date time.Time) (Money, error) {
+	if m.Currency == to || m.Currency == "" {
+		return Money{Units: m.Units, Currency: to}, nil
+	}
+
+	rate, err := r.Get(m.Currency, to, date)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return m.Convert(to, rate), nil
+}
+
+var rates *Rates
+
+// Returns the exchange rates, reading them the first time they're needed
+func getRates() (*Rates, error) {
+	if rates != nil {
+		return rates, nil
+	}
+
+	r, err := LoadRates(config.GetRatesFile())
+	if err != nil {
+		return nil, err
+	}
+
+	rates = r
+	return rates, nil
+}
+
+// Converts an amount into the base currency, at its rate on some date
+func toBase(m Money, date time.Time) (Money, error) {
+	base := config.GetBaseCurrency()
+	if m.Currency == base || m.Currency == "" {
+		return Money{Units: m.Units, Currency: base}, nil
+	}
+
+	r, err := getRates()
+	if err != nil {
+		return Money{}, err
+	}
+
+	return r.Convert(m, base, date)
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount   string
+		from     string
+		to       string
+		rate     string
+		expected string
+	}{
+		{"10", "EUR", "USD", "1.0842", "10.84"},
+		{"0.05", "EUR", "USD", "1.1", "0.06"},
+		{"-0.05", "EUR", "USD", "1.1", "-0.06"},
+		{"1500", "JPY", "USD", "0.0067", "10.05"},
+		{"10", "USD", "JPY", "149.555", "1496"},
+		{"1", "KWD", "USD", "3.2555", "3.26"},
+	}
+
+	for _, test := range tests {
+		m, err := ParseMoney(test.amount, test.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rate, _ := new(big.Rat).SetString(test.rate)
+		if got := m.Convert(test.to, rate).Decimal(); got != test.expected {
+			t.Errorf("%s %s at %s = %s %s, expected %s", test.amount, test.from, test.rate, got, test.to, test.expected)
+		}
+	}
+}
+
+func TestRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	data := "date,from,to,rate\n2026-09-01,EUR,USD,1.10\n2026-09-15,EUR,USD,1.20\n2026-09-01,USD,BRL,5\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := LoadRates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time {
+		return time.Date(2026, time.September, d, 18, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		amount   string
+		from     string
+		to       string
+		date     time.Time
+		expected string
+	}{
+		{"10", "EUR", "USD", day(1), "11.00"},
+		{"10", "EUR", "USD", day(14), "11.00"},
+		{"10", "EUR", "USD", day(15), "12.00"},
+		{"12", "USD", "EUR", day(20), "10.00"},
+		{"10", "BRL", "USD", day(2), "2.00"},
+	}
+
+	for _, test := range tests {
+		m, _ := ParseMoney(test.amount, test.from)
+
+		converted, err := r.Convert(m, test.to, test.date)
+		if err != nil {
+			t.Errorf("Converting %s %s: %v", test.amount, test.from, err)
+		} else if converted.Decimal() != test.expected {
+			t.Errorf("%s %s = %s %s, expected %s", test.amount, test.from, converted.Decimal(), test.to, test.expected)
+		}
+	}
+
+	// Before the first rate there's nothing to go by
+	m, _ := ParseMoney("10", "EUR")
+	if _, err := r.Convert(m, "USD", time.Date(2026, time.August, 31, 0, 0, 0, 0, time.Local)); err == nil {
+		t.Errorf("Expected no rate before 2026-09-01")
+	}
+
+	if _, err := r.Convert(m, "BRL", day(20)); err == nil {
+		t.Errorf("Expected no rate from EUR to BRL")
+	}
+}
+
+// Returns a context with the given flags set, like the command line would
+func newContext(t *testing.T, flags map[string]string) *cli.Context {
+	t.Helper()
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for name, value := range flags {
+		set.String(name, "", "")
+		if err := set.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func TestUpdateKeepsCurrency(t *testing.T) {
+	euros, err := ParseMoney("10", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	useExpenses(t, &Expenses{Expenses: map[uint64]Expense{
+		1: expenseAt(1, time.Date(2026, time.September, 1, 12, 0, 0, 0, time.Local), euros),
+	}})
+
+	if err := HandleUpdate(newContext(t, map[string]string{"id": "1", "amount": "12"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := expenses.Expenses[1].Amount; got != (Money{1200, "EUR"}) {
+		t.Errorf("Updating the amount gave %s, expected 12.00 EUR", got)
+	}
+
+	if err := HandleUpdate(newContext(t, map[string]string{"id": "1", "amount": "1500", "currency": "JPY"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := expenses.Expenses[1].Amount; got != (Money{1500, "JPY"}) {
+		t.Errorf("Updating the amount and currency gave %s, expected 1500 JPY", got)
+	}
+}
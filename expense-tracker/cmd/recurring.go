@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// How often recurring entries happen
+const (
+	EVERY_DAY   = "daily"
+	EVERY_WEEK  = "weekly"
+	EVERY_MONTH = "monthly"
+	EVERY_YEAR  = "yearly"
+)
+
+var Frequencies []string = []string{EVERY_DAY, EVERY_WEEK, EVERY_MONTH, EVERY_YEAR}
+
+// An expense (or income) that happens on a schedule, like rent or a streaming
+// service. Its occurrences are added as they come due
+type Recurring struct {
+	ID          uint64 `json:"id"`
+	Description string `json:"description"`
+	Amount      Money  `json:"amount"`
+	Category    string `json:"category,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Every       string `json:"every"`
+
+	// The day of the month (for monthly entries) or of the week (for weekly
+	// ones, with Monday as 1) it happens on. Days past the end of a month
+	// happen on its last day
+	Day int `json:"day,omitempty"`
+
+	// When it happens next, which is the first occurrence not added yet
+	Next   time.Time `json:"next"`
+	Paused bool      `json:"paused,omitempty"`
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local).Day()
+}
+
+// Returns the day in a month that's closest to a day of the month
+func dayInMonth(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, min(day, daysIn(year, month)), 0, 0, 0, 0, time.Local)
+}
+
+// Returns the first occurrence on or after a day
+func (r Recurring) firstFrom(day time.Time) time.Time {
+	day = startOfDay(day)
+
+	switch r.Every {
+	case EVERY_WEEK:
+		weekday := time.Weekday(r.Day % 7)
+		return day.AddDate(0, 0, (int(weekday)-int(day.Weekday())+7)%7)
+	case EVERY_MONTH:
+		first := dayInMonth(day.Year(), day.Month(), r.Day)
+		if first.Before(day) {
+			first = dayInMonth(day.Year(), day.Month()+1, r.Day)
+		}
+
+		return first
+	default:
+		return day
+	}
+}
+
+// Returns the occurrence after another one
+func (r Recurring) after(occurrence time.Time) time.Time {
+	switch r.Every {
+	case EVERY_DAY:
+		return occurrence.AddDate(0, 0, 1)
+	case EVERY_WEEK:
+		return occurrence.AddDate(0, 0, 7)
+	case EVERY_MONTH:
+		return dayInMonth(occurrence.Year(), occurrence.Month()+1, r.Day)
+	default:
+		// Yearly entries on February 29 happen on the 28th in other years
+		return dayInMonth(occurrence.Year()+1, occurrence.Month(), r.Day)
+	}
+}
+
+// Returns the occurrences up to (and including) a day, starting from the next
+func (r Recurring) occurrencesUntil(day time.Time) []time.Time {
+	occurrences := []time.Time{}
+	for next := r.Next; !next.After(day); next = r.after(next) {
+		occurrences = append(occurrences, next)
+	}
+
+	return occurrences
+}
+
+func (r Recurring) String() string {
+	amount := r.Amount.String()
+	if r.Kind == KIND_INCOME {
+		amount = "+" + amount
+	}
+
+	status := "active"
+	if r.Paused {
+		status = "paused"
+	}
+
+	return fmt.Sprintf(
+		"%-3d %-16s %-12s %-13s %-9s %-11s %s",
+		r.ID, r.Description, orDash(r.Category), amount,
+		r.Every, r.Next.Format(DATE_FORMAT), status,
+	)
+}
+
+func orDash(text string) string {
+	if text == "" {
+		return "-"
+	}
+
+	return text
+}
+
+// Adds the occurrences of recurring entries that came due up to a day, and
+// returns them. Each occurrence is only ever added once, even if the tool
+// hasn't been run in a while, since the next one is kept track of
+func (e *Expenses) addDueRecurring(now time.Time) []Expense {
+	today := startOfDay(now)
+	added := []Expense{}
+
+	for _, id := range slices.Sorted(maps.Keys(e.Recurring)) {
+		r := e.Recurring[id]
+		if r.Paused {
+			continue
+		}
+
+		for _, occurrence := range r.occurrencesUntil(today) {
+			expenseID := e.addExpense(occurrence, r.Description, r.Amount, r.Category)
+
+			expense := e.Expenses[expenseID]
+			expense.Kind = r.Kind
+			expense.RecurringID = r.ID
+			e.Expenses[expenseID] = expense
+
+			added = append(added, expense)
+			r.Next = r.after(occurrence)
+		}
+
+		e.Recurring[id] = r
+	}
+
+	return added
+}
+
+// An occurrence of a recurring entry that's still to come
+type Upcoming struct {
+	Date      time.Time
+	Recurring Recurring
+}
+
+// Returns the occurrences of the active recurring entries from tomorrow up to
+// some day, in order
+func (e *Expenses) getUpcoming(now, until time.Time) []Upcoming {
+	upcoming := []Upcoming{}
+	for _, r := range e.Recurring {
+		if r.Paused {
+			continue
+		}
+
+		// The ones due today were already added
+		from := startOfDay(now).AddDate(0, 0, 1)
+		if r.Next.Before(from) {
+			r.Next = r.firstFrom(from)
+		}
+
+		for _, date := range r.occurrencesUntil(until) {
+			upcoming = append(upcoming, Upcoming{date, r})
+		}
+	}
+
+	slices.SortStableFunc(upcoming, func(a, b Upcoming) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+
+		return int(a.Recurring.ID) - int(b.Recurring.ID)
+	})
+
+	return upcoming
+}
+
+// Prints the occurrences of recurring entries that were just added
+func printAddedRecurring(added []Expense) {
+	for _, expense := range added {
+		fmt.Printf(
+			"Added recurring '%s' for %s (ID: %d)\n",
+			expense.Description, expense.CreatedAt.Format(DATE_FORMAT), expense.ID,
+		)
+	}
+}
+
+// Parses the day recurring entries happen on. Weekly ones can be given the
+// name of the weekday
+func parseRecurringDay(every, text string) (int, error) {
+	if every == EVERY_WEEK {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(text, weekday.String()) {
+				return (int(weekday)+6)%7 + 1, nil
+			}
+		}
+	}
+
+	day, err := strconv.Atoi(text)
+
+	switch every {
+	case EVERY_WEEK:
+		if err != nil || day < 1 || day > 7 {
+			return 0, fmt.Errorf("'%s' is not a day of the week (must be 1-7, from Monday, or a name like friday)", text)
+		}
+	case EVERY_MONTH:
+		if err != nil || day < 1 || day > 31 {
+			return 0, fmt.Errorf("'%s' is not a day of the month (must be 1-31)", text)
+		}
+	default:
+		return 0, errors.New("--day can only be given to weekly and monthly entries!")
+	}
+
+	return day, nil
+}
+
+func getRecurring(ctx *cli.Context) (Recurring, error) {
+	id, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return Recurring{}, errors.New("Must provide the ID of a recurring entry!")
+	}
+
+	r, ok := expenses.Recurring[id]
+	if !ok {
+		return Recurring{}, fmt.Errorf("No recurring entry with ID %v!", id)
+	}
+
+	return r, nil
+}
+
+func HandleRecurringAdd(ctx *cli.Context) error {
+	description := ctx.String("description")
+	if description == "" {
+		return errors.New("Must provide a description for the recurring entry!")
+	}
+
+	if !ctx.IsSet("amount") {
+		return errors.New("Must provide the amount of the recurring entry!")
+	}
+
+	amount, err := getAmount(ctx, config.GetBaseCurrency())
+	if err != nil {
+		return err
+	}
+
+	category, err := getCategory(ctx)
+	if err != nil {
+		return err
+	}
+
+	kind, err := getKind(ctx)
+	if err != nil {
+		return err
+	}
+
+	every := strings.ToLower(ctx.String("every"))
+	if !slices.Contains(Frequencies, every) {
+		return fmt.Errorf("'%s' is not how often entries can happen (must be one of %s)", every, strings.Join(Frequencies, ", "))
+	}
+
+	start, err := getDate(ctx, "start")
+	if err != nil {
+		return err
+	}
+
+	r := Recurring{
+		Description: description,
+		Amount:      amount,
+		Category:    category,
+		Kind:        kind,
+		Every:       every,
+	}
+
+	switch {
+	case ctx.IsSet("day"):
+		r.Day, err = parseRecurringDay(every, ctx.String("day"))
+		if err != nil {
+			return err
+		}
+	case every == EVERY_WEEK:
+		r.Day = (int(start.Weekday())+6)%7 + 1
+	case every == EVERY_MONTH, every == EVERY_YEAR:
+		r.Day = start.Day()
+	}
+
+	r.Next = r.firstFrom(start)
+
+	// IDs aren't reused, so that a new entry doesn't take the occurrences of
+	// a deleted one as its own
+	for id := range expenses.Recurring {
+		r.ID = max(r.ID, id)
+	}
+
+	r.ID++
+
+	if expenses.Recurring == nil {
+		expenses.Recurring = map[uint64]Recurring{}
+	}
+
+	expenses.Recurring[r.ID] = r
+	fmt.Printf("Recurring entry added successfully (ID: %d), next on %s\n", r.ID, r.Next.Format(DATE_FORMAT))
+
+	// It might be due already
+	printAddedRecurring(expenses.addDueRecurring(time.Now()))
+	return nil
+}
+
+func HandleRecurringList(ctx *cli.Context) error {
+	if len(expenses.Recurring) == 0 {
+		fmt.Println("There are no recurring entries to display!")
+		return nil
+	}
+
+	fmt.Println("ID  Description      Category     Amount        Every     Next        Status")
+	for _, id := range slices.Sorted(maps.Keys(expenses.Recurring)) {
+		fmt.Println(expenses.Recurring[id].String())
+	}
+
+	return nil
+}
+
+func HandleRecurringPause(ctx *cli.Context) error {
+	r, err := getRecurring(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.Paused = true
+	expenses.Recurring[r.ID] = r
+
+	fmt.Println("Recurring entry paused successfully")
+	return nil
+}
+
+// Resumes a paused entry. Occurrences missed while it was paused aren't added
+func HandleRecurringResume(ctx *cli.Context) error {
+	r, err := getRecurring(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := startOfDay(time.Now())
+	if r.Next.Before(today) {
+		r.Next = r.firstFrom(today)
+	}
+
+	r.Paused = false
+	expenses.Recurring[r.ID] = r
+
+	fmt.Printf("Recurring entry resumed successfully, next on %s\n", r.Next.Format(DATE_FORMAT))
+	printAddedRecurring(expenses.addDueRecurring(time.Now()))
+
+	return nil
+}
+
+// Deletes a recurring entry. The occurrences already added are kept
+func HandleRecurringDelete(ctx *cli.Context) error {
+	r, err := getRecurring(ctx)
+	if err != nil {
+		return err
+	}
+
+	delete(expenses.Recurring, r.ID)
+
+	fmt.Println("Recurring entry deleted successfully")
+	return nil
+}
+
+// Lists the upcoming occurrences of recurring entries, for the next days
+func HandleRecurringForecast(ctx *cli.Context) error {
+	days := ctx.Int("days")
+	if days < 1 {
+		return fmt.Errorf("'%d' is not a valid number of days!", days)
+	}
+
+	now := time.Now()
+	until := startOfDay(now).AddDate(0, 0, days)
+
+	upcoming := expenses.getUpcoming(now, until)
+	if len(upcoming) == 0 {
+		fmt.Printf("Nothing is coming up in the next %d days\n", days)
+		return nil
+	}
+
+	base := config.GetBaseCurrency()
+	income, charges := Money{Currency: base}, Money{Currency: base}
+
+	fmt.Println("Date        Description      Category     Amount")
+	for _, u := range upcoming {
+		r := u.Recurring
+
+		amount := r.Amount.String()
+		if r.Kind == KIND_INCOME {
+			amount = "+" + amount
+		}
+
+		fmt.Printf("%-11s %-16s %-12s %s\n", u.Date.Format(DATE_FORMAT), r.Description, orDash(r.Category), amount)
+
+		converted, err := toBase(r.Amount, u.Date)
+		if err != nil {
+			return fmt.Errorf("Can't convert recurring entry %d: %v", r.ID, err)
+		}
+
+		if r.Kind == KIND_INCOME {
+			income = income.Add(converted)
+		} else {
+			charges = charges.Add(converted)
+		}
+	}
+
+	fmt.Printf("Charges in the next %d days: %s\n", days, charges)
+	if !income.IsZero() {
+		fmt.Printf("Income in the next %d days: %s\n", days, income)
+	}
+
+	return nil
+}
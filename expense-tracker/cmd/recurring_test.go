@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddDueRecurring(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.Local)
+	}
+
+	r := Recurring{ID: 1, Description: "Rent", Amount: usd(t, "1200"), Every: EVERY_MONTH, Day: 31}
+	r.Next = r.firstFrom(date(time.January, 15))
+
+	e := &Expenses{Expenses: map[uint64]Expense{}, Recurring: map[uint64]Recurring{1: r}}
+
+	// Weeks without running the tool still add every month once
+	added := e.addDueRecurring(date(time.April, 29).Add(20 * time.Hour))
+
+	expected := []time.Time{date(time.January, 31), date(time.February, 28), date(time.March, 31)}
+	if len(added) != len(expected) {
+		t.Fatalf("Expected %d occurrences, got %d", len(expected), len(added))
+	}
+
+	for idx, expense := range added {
+		if !expense.CreatedAt.Equal(expected[idx]) || expense.RecurringID != 1 {
+			t.Errorf("Occurrence %d: expected it on %s, got %+v", idx, expected[idx], expense)
+		}
+	}
+
+	if again := e.addDueRecurring(date(time.April, 29)); len(again) != 0 {
+		t.Errorf("Expected nothing new to be added, got %d", len(again))
+	}
+
+	if next := e.Recurring[1].Next; !next.Equal(date(time.April, 30)) {
+		t.Errorf("Expected the next occurrence on 2026-04-30, got %s", next)
+	}
+}
+
+func TestWeeklyRecurring(t *testing.T) {
+	// Fridays, starting on a Wednesday
+	r := Recurring{Every: EVERY_WEEK, Day: 5}
+	r.Next = r.firstFrom(time.Date(2026, time.September, 30, 0, 0, 0, 0, time.Local))
+
+	occurrences := r.occurrencesUntil(time.Date(2026, time.October, 16, 0, 0, 0, 0, time.Local))
+	if len(occurrences) != 3 || occurrences[0].Day() != 2 || occurrences[2].Day() != 16 {
+		t.Errorf("Expected Fridays the 2nd, 9th and 16th, got %v", occurrences)
+	}
+}
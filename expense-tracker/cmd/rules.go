@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Puts imported expenses whose descriptions (the payees, in bank statements)
+// have some text in a category
+type CategoryRule struct {
+	Match    string `json:"match"`
+	Category string `json:"category"`
+}
+
+// Returns the category of the first rule a description matches, if any does.
+// Matching doesn't care about case
+func (c *Config) getRuleCategory(description string) string {
+	description = strings.ToLower(description)
+	for _, rule := range c.CategoryRules {
+		if strings.Contains(description, strings.ToLower(rule.Match)) {
+			return rule.Category
+		}
+	}
+
+	return ""
+}
+
+func HandleRuleAdd(ctx *cli.Context) error {
+	match := strings.TrimSpace(ctx.String("match"))
+	if match == "" {
+		return errors.New("Must provide the text to match!")
+	}
+
+	category, err := getCategory(ctx)
+	if err != nil {
+		return err
+	}
+
+	if category == "" {
+		return errors.New("Must provide the category to put expenses in!")
+	}
+
+	config.CategoryRules = append(config.CategoryRules, CategoryRule{match, category})
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added rule %d: '%s' goes in '%s'\n", len(config.CategoryRules), match, category)
+	return nil
+}
+
+func HandleRuleList(ctx *cli.Context) error {
+	if len(config.CategoryRules) == 0 {
+		fmt.Println("There are no rules to display!")
+		return nil
+	}
+
+	fmt.Println("#   Match                    Category")
+	for idx, rule := range config.CategoryRules {
+		fmt.Printf("%-3d %-24s %s\n", idx+1, rule.Match, rule.Category)
+	}
+
+	return nil
+}
+
+func HandleRuleDelete(ctx *cli.Context) error {
+	n, err := strconv.Atoi(ctx.Args().Get(0))
+	if err != nil || n < 1 || n > len(config.CategoryRules) {
+		return fmt.Errorf("'%s' is not a rule number (see rule list)", ctx.Args().Get(0))
+	}
+
+	config.CategoryRules = append(config.CategoryRules[:n-1], config.CategoryRules[n:]...)
+	if err := saveConfig(); err != nil {
+		return err
+	}
+
+	fmt.Println("Rule deleted successfully")
+	return nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	MONTH_FORMAT = "2006-01"
+
+	// Width of a day in the calendar, as in "12 (3)"
+	CALENDAR_CELL_WIDTH = 7
+)
+
+// Reads the --tz flag, falling back to the local timezone
+func getLocation(ctx *cli.Context) (*time.Location, error) {
+	if ctx.String("tz") == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(ctx.String("tz"))
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid timezone!", ctx.String("tz"))
+	}
+
+	return loc, nil
+}
+
+func HandleAgenda(ctx *cli.Context) error {
+	loc, err := getLocation(ctx)
+	if err != nil {
+		return err
+	}
+
+	groups := manager.Agenda(loc)
+	if len(groups) == 0 {
+		fmt.Println("There are no pending tasks with a due date!")
+		return nil
+	}
+
+	for idx, group := range groups {
+		if idx > 0 {
+			fmt.Println()
+		}
+
+		role := ROLE_HEADER
+		if group.Name == tasks.AGENDA_OVERDUE {
+			role = ROLE_OVERDUE
+		}
+
+		fmt.Println(palette.Paint(role, fmt.Sprintf("== %s (%d) ==", group.Name, len(group.Tasks))))
+		for _, task := range group.Tasks {
+			fmt.Printf(
+				"%-4d %-*s %s %s\n", task.Id, cfg.Int("list.desc-width"), task.Description,
+				task.Due.Format("Mon"), task.Due.Format(tasks.DUE_FORMAT),
+			)
+		}
+	}
+
+	return nil
+}
+
+func HandleCalendar(ctx *cli.Context) error {
+	loc, err := getLocation(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := manager.Now().In(loc)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if ctx.IsSet("month") {
+		month, err = time.Parse(MONTH_FORMAT, ctx.String("month"))
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid month (must be YYYY-MM)", ctx.String("month"))
+		}
+	}
+
+	counts := manager.DueCounts(month.Year(), month.Month())
+	title := month.Format("January 2006")
+
+	width := CALENDAR_CELL_WIDTH * 7
+	fmt.Printf("%*s\n", (width+len(title))/2, title)
+
+	header := ""
+	for _, day := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		header += fmt.Sprintf("%-*s", CALENDAR_CELL_WIDTH, day)
+	}
+
+	fmt.Println(strings.TrimRight(header, " "))
+
+	// Weeks start on Monday
+	line := strings.Repeat(" ", CALENDAR_CELL_WIDTH*((int(month.Weekday())+6)%7))
+	lastDay := month.AddDate(0, 1, -1).Day()
+
+	for day := 1; day <= lastDay; day++ {
+		cell := fmt.Sprintf("%2d", day)
+		if counts[day] > 0 {
+			cell += fmt.Sprintf(" (%d)", counts[day])
+		}
+
+		line += fmt.Sprintf("%-*s", CALENDAR_CELL_WIDTH, cell)
+
+		if date := month.AddDate(0, 0, day-1); date.Weekday() == time.Sunday || day == lastDay {
+			fmt.Println(strings.TrimRight(line, " "))
+			line = ""
+		}
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	fmt.Printf("\n%d pending task(s) due in %s\n", total, title)
+	return nil
+}
@@ -23,27 +23,77 @@ func New() *cli.App {
 		Before: Load,
 		After:  Save,
 
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Config file to use (defaults to ~/.config/task-cli/config.toml)",
+			},
+			&cli.StringFlag{
+				Name:  "db",
+				Usage: "Task database to use (defaults to db.json)",
+			},
+			&cli.StringFlag{
+				Name:  "color",
+				Usage: "When to colour the output: auto, always or never (auto checks for a terminal and $NO_COLOR)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-hooks",
+				Usage: "Doesn't run any hooks",
+			},
+		},
+
 		Commands: []*cli.Command{
 			{
 				Name:      "add",
 				Aliases:   []string{"a"},
 				Usage:     "Adds a new task",
-				UsageText: "task-cli [add, a] [task name]",
+				UsageText: "task-cli [add, a] [--tag tag...] [task name]",
 				Action:    HandleAdd,
+				Flags: append(fieldFlags(),
+					&cli.StringSliceFlag{
+						Name:    "tag",
+						Aliases: []string{"t"},
+						Usage:   "Tags the task (can be given more than once)",
+					},
+				),
 			},
 			{
 				Name:      "update",
 				Aliases:   []string{"u"},
 				Usage:     "Updates a task",
-				UsageText: "task-cli [update, u] [task id] [task name]",
-				Action:    HandleUpdate,
+				UsageText: "task-cli [update, u] [task ids] [--status status] [--tag tag...] [task name]",
+				Flags: append(fieldFlags(),
+					&cli.StringFlag{
+						Name:    "status",
+						Aliases: []string{"s"},
+						Usage:   "Selects tasks with the given status (todo, in-progress, done)",
+					},
+					&cli.StringSliceFlag{
+						Name:    "tag",
+						Aliases: []string{"t"},
+						Usage:   "Selects tasks with the given tag (can be given more than once)",
+					},
+				),
+				Action: HandleUpdate,
 			},
 			{
 				Name:      "delete",
 				Aliases:   []string{"d"},
 				Usage:     "Deletes a task",
-				UsageText: "task-cli [delete, d] <task id or status>",
-				Action:    HandleDelete,
+				UsageText: "task-cli [delete, d] <task ids or status> [--status status] [--tag tag...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "status",
+						Aliases: []string{"s"},
+						Usage:   "Selects tasks with the given status (todo, in-progress, done)",
+					},
+					&cli.StringSliceFlag{
+						Name:    "tag",
+						Aliases: []string{"t"},
+						Usage:   "Selects tasks with the given tag (can be given more than once)",
+					},
+				},
+				Action: HandleDelete,
 				Subcommands: []*cli.Command{
 					{
 						Name:     "done",
@@ -72,67 +122,396 @@ func New() *cli.App {
 				Name:      "mark-in-progress",
 				Aliases:   []string{"mp"},
 				Usage:     "Marks a task as in-progress",
-				UsageText: "task-cli [mark-in-progress, mp] [task id]",
-				Action:    HandleMarkInProgress,
+				UsageText: "task-cli [mark-in-progress, mp] [task ids] [--status status] [--tag tag...] [--force]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Ignores the status' WIP limit",
+					},
+					&cli.StringFlag{
+						Name:    "status",
+						Aliases: []string{"s"},
+						Usage:   "Selects tasks with the given status (todo, in-progress, done)",
+					},
+					&cli.StringSliceFlag{
+						Name:    "tag",
+						Aliases: []string{"t"},
+						Usage:   "Selects tasks with the given tag (can be given more than once)",
+					},
+				},
+				Action: HandleMarkInProgress,
 			},
 			{
 				Name:      "mark-done",
 				Aliases:   []string{"md"},
 				Usage:     "Marks a task as done",
-				UsageText: "task-cli [mark-done, md] [task id]",
-				Action:    HandleMarkDone,
+				UsageText: "task-cli [mark-done, md] [task ids] [--status status] [--tag tag...] [--force]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Ignores the status' WIP limit",
+					},
+					&cli.StringFlag{
+						Name:    "status",
+						Aliases: []string{"s"},
+						Usage:   "Selects tasks with the given status (todo, in-progress, done)",
+					},
+					&cli.StringSliceFlag{
+						Name:    "tag",
+						Aliases: []string{"t"},
+						Usage:   "Selects tasks with the given tag (can be given more than once)",
+					},
+				},
+				Action: HandleMarkDone,
 			},
 			{
-				Name:      "list",
-				Aliases:   []string{"l"},
-				Usage:     "Lists all tasks",
-				UsageText: "task-cli [list, l] <type>",
+				Name:      "archive",
+				Aliases:   []string{"ar"},
+				Usage:     "Archives a task, hiding it from the list",
+				UsageText: "task-cli [archive, ar] <task id or status> [--older-than age]",
+				Action:    HandleArchive,
+				Flags:     archiveFlags(),
+				Subcommands: []*cli.Command{
+					{
+						Name:     "done",
+						Aliases:  []string{"d"},
+						Usage:    "Archives all completed tasks",
+						Action:   HandleArchiveDone,
+						Category: "list",
+						Flags:    archiveFlags(),
+					},
+					{
+						Name:     "todo",
+						Aliases:  []string{"t"},
+						Usage:    "Archives all tasks that are yet to be started",
+						Action:   HandleArchiveTodo,
+						Category: "list",
+						Flags:    archiveFlags(),
+					},
+					{
+						Name:     "in-progress",
+						Aliases:  []string{"p"},
+						Usage:    "Archives all in-progress tasks",
+						Action:   HandleArchiveInProgress,
+						Category: "list",
+						Flags:    archiveFlags(),
+					},
+				},
+			},
+			{
+				Name:      "unarchive",
+				Aliases:   []string{"ua"},
+				Usage:     "Brings an archived task back to the list",
+				UsageText: "task-cli [unarchive, ua] [task id]",
+				Action:    HandleUnarchive,
+			},
+			{
+				Name:      "log",
+				Usage:     "Shows the history of a task",
+				UsageText: "task-cli log [task id]",
+				Action:    HandleLog,
+			},
+			{
+				Name:      "apply",
+				Usage:     "Adds the tasks in a template",
+				UsageText: "task-cli apply [--var name=value...] [template name or file]",
+				Action:    HandleApply,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "var",
+						Usage: "Sets a template variable, as name=value (can be given more than once)",
+					},
+					templateDirFlag(),
+				},
+			},
+			{
+				Name:      "template",
+				Aliases:   []string{"tpl"},
+				Usage:     "Manages task templates",
+				UsageText: "task-cli [template, tpl] [list, show]",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "Lists all templates",
+						Action: HandleTemplateList,
+						Flags:  []cli.Flag{templateDirFlag()},
+					},
+					{
+						Name:      "show",
+						Usage:     "Shows a template's variables and tasks",
+						UsageText: "task-cli template show [template name or file]",
+						Action:    HandleTemplateShow,
+						Flags:     []cli.Flag{templateDirFlag()},
+					},
+				},
+			},
+			{
+				Name:      "agenda",
+				Aliases:   []string{"ag"},
+				Usage:     "Shows pending tasks by when they're due",
+				UsageText: "task-cli [agenda, ag] [--tz timezone]",
+				Action:    HandleAgenda,
+				Flags:     []cli.Flag{tzFlag()},
+			},
+			{
+				Name:      "calendar",
+				Aliases:   []string{"cal"},
+				Usage:     "Shows how many tasks are due on each day of a month",
+				UsageText: "task-cli [calendar, cal] [--month YYYY-MM] [--tz timezone]",
+				Action:    HandleCalendar,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "month",
+						Usage: "Month to show, as YYYY-MM (defaults to the current one)",
+					},
+					tzFlag(),
+				},
+			},
+			{
+				Name:      "plan",
+				Usage:     "Picks the to-do tasks that fit in some capacity, and sums up estimates",
+				UsageText: "task-cli plan [--capacity 20h]",
+				Action:    HandlePlan,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "capacity",
+						Usage: "How much work to plan for, in hours or points, like 20h or 13pt",
+					},
+				},
+			},
+			{
+				Name:      "scan",
+				Usage:     "Adds tasks for the TODO, FIXME and HACK comments in a directory",
+				UsageText: "task-cli scan [directory]",
+				Action:    HandleScan,
+			},
+			{
+				Name:      "board",
+				Aliases:   []string{"b"},
+				Usage:     "Shows tasks as a board, with a column per status",
+				UsageText: "task-cli [board, b] [--width columns]",
+				Action:    HandleBoard,
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "width",
+						Usage: "Width of the board (defaults to the terminal's width)",
+					},
+				},
+			},
+			{
+				Name:      "wip",
+				Usage:     "Shows or sets work-in-progress limits",
+				UsageText: "task-cli wip [--warn-only] <status> <limit>",
+				Action:    HandleWip,
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
-						Name:    "verbose",
-						Aliases: []string{"v"},
+						Name:  "warn-only",
+						Usage: "Only warns when the limit is exceeded, instead of refusing",
+					},
+				},
+			},
+			{
+				Name:      "hook",
+				Usage:     "Manages the scripts run when tasks change",
+				UsageText: "task-cli hook [list]",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "Lists the hooks for each event",
+						Action: HandleHookList,
+					},
+				},
+			},
+			{
+				Name:      "config",
+				Usage:     "Manages settings",
+				UsageText: "task-cli config [get, set, unset, list]",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "get",
+						Usage:     "Shows a setting's value",
+						UsageText: "task-cli config get [key]",
+						Action:    HandleConfigGet,
+					},
+					{
+						Name:      "set",
+						Usage:     "Sets a setting in the config file",
+						UsageText: "task-cli config set [key] [value]",
+						Action:    HandleConfigSet,
+					},
+					{
+						Name:      "unset",
+						Usage:     "Removes a setting from the config file",
+						UsageText: "task-cli config unset [key]",
+						Action:    HandleConfigUnset,
+					},
+					{
+						Name:   "list",
+						Usage:  "Lists all settings, their values and where they came from",
+						Action: HandleConfigList,
 					},
 				},
-				Action: HandleList,
+			},
+			{
+				Name:      "db",
+				Usage:     "Manages the task database",
+				UsageText: "task-cli db [encrypt, decrypt]",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "encrypt",
+						Usage:  "Encrypts the database with a passphrase",
+						Action: HandleDBEncrypt,
+					},
+					{
+						Name:   "decrypt",
+						Usage:  "Stores the database in plaintext again",
+						Action: HandleDBDecrypt,
+					},
+				},
+			},
+			{
+				Name:      "list",
+				Aliases:   []string{"l"},
+				Usage:     "Lists all tasks",
+				UsageText: "task-cli [list, l] <type> [--sort field] [--group-by field]",
+				Flags:     listFlags(),
+				Action:    HandleList,
 				Subcommands: []*cli.Command{
 					{
 						Name:     "done",
 						Aliases:  []string{"d"},
 						Usage:    "Lists all completed tasks",
 						Action:   HandleListDone,
-						Category: "list", Flags: []cli.Flag{
-							&cli.BoolFlag{
-								Name:    "verbose",
-								Aliases: []string{"v"},
-							},
-						},
+						Category: "list",
+						Flags:    listFlags(),
 					},
 					{
 						Name:     "todo",
 						Aliases:  []string{"t"},
 						Usage:    "Lists all tasks that are yet to be started",
 						Action:   HandleListTodo,
-						Category: "list", Flags: []cli.Flag{
-							&cli.BoolFlag{
-								Name:    "verbose",
-								Aliases: []string{"v"},
-							},
-						},
+						Category: "list",
+						Flags:    listFlags(),
 					},
 					{
 						Name:     "in-progress",
 						Aliases:  []string{"p"},
 						Usage:    "Lists all in-progress tasks",
 						Action:   HandleListInProgress,
-						Category: "list", Flags: []cli.Flag{
-							&cli.BoolFlag{
-								Name:    "verbose",
-								Aliases: []string{"v"},
-							},
-						},
+						Category: "list",
+						Flags:    listFlags(),
 					},
 				},
 			},
 		},
 	}
 }
+
+// Flags shared by the list command and its subcommands
+func listFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Aliases: []string{"v"},
+		},
+		&cli.BoolFlag{
+			Name:  "archived",
+			Usage: "Lists archived tasks instead",
+		},
+		&cli.StringFlag{
+			Name:  "sort",
+			Usage: "Sorts by id, created, updated, due, estimate, priority, status or description",
+			Value: "id",
+		},
+		&cli.BoolFlag{
+			Name:  "desc",
+			Usage: "Sorts in descending order",
+		},
+		&cli.StringFlag{
+			Name:  "group-by",
+			Usage: "Groups by status, tag, project or due-week",
+		},
+		&cli.StringFlag{
+			Name:  "min-estimate",
+			Usage: "Lists tasks estimated at least this much, like 2h or 3pt",
+		},
+		&cli.StringFlag{
+			Name:  "max-estimate",
+			Usage: "Lists tasks estimated at most this much, like 2h or 3pt",
+		},
+		&cli.BoolFlag{
+			Name:  "unestimated",
+			Usage: "Lists tasks without an estimate",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "Shows at most this many tasks",
+		},
+		&cli.IntFlag{
+			Name:  "offset",
+			Usage: "Skips this many tasks before listing",
+		},
+		&cli.BoolFlag{
+			Name:    "watch",
+			Aliases: []string{"w"},
+			Usage:   "Lists again whenever the tasks change, highlighting what changed (Ctrl+C stops)",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "How often to check for changes when watching",
+			Value: time.Second,
+		},
+	}
+}
+
+// Flags shared by archive and its subcommands
+func archiveFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "older-than",
+			Usage: "Only archives tasks not updated in the given age (e.g. 30d, 2w, 12h)",
+		},
+	}
+}
+
+// Flags for setting a task's fields, shared by add and update
+func fieldFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "project",
+			Usage: "The project the task belongs to",
+		},
+		&cli.StringFlag{
+			Name:  "priority",
+			Usage: "The task's priority (none, low, medium, high)",
+		},
+		&cli.StringFlag{
+			Name:  "due",
+			Usage: "The date the task is due, as YYYY-MM-DD (empty clears it)",
+		},
+		&cli.Uint64Flag{
+			Name:  "parent",
+			Usage: "The ID of the task's parent (0 clears it)",
+		},
+		&cli.StringFlag{
+			Name:  "estimate",
+			Usage: "How much work the task is, in hours or points, like 3h, 90m or 5pt (empty clears it)",
+		},
+	}
+}
+
+func templateDirFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "dir",
+		Usage: "Directory to look for templates in (defaults to ~/.config/task-cli/templates)",
+	}
+}
+
+func tzFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "tz",
+		Usage: "Timezone that decides what \"today\" is, like America/Sao_Paulo (defaults to the local one)",
+	}
+}
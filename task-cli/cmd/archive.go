@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+// Parses an age such as "30d", "2w" or "12h"
+//
+// Days and weeks aren't supported by time.ParseDuration, so they're handled
+// here, and anything else is handed over to it
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, errors.New("Must provide an age")
+	}
+
+	unit := age[len(age)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(strings.TrimSpace(age[:len(age)-1]))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("'%s' is not a valid age!", age)
+		}
+
+		days := n
+		if unit == 'w' {
+			days *= 7
+		}
+
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("'%s' is not a valid age!", age)
+	}
+
+	return d, nil
+}
+
+func getCutoff(ctx *cli.Context) (time.Time, error) {
+	if !ctx.IsSet("older-than") {
+		return time.Time{}, nil
+	}
+
+	age, err := parseAge(ctx.String("older-than"))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return manager.Now().Add(-age), nil
+}
+
+func archiveByStatus(ctx *cli.Context, status *tasks.TaskStatus) error {
+	cutoff, err := getCutoff(ctx)
+	if err != nil {
+		return err
+	}
+
+	archived := manager.ArchiveWhere(status, cutoff)
+	fmt.Printf("Archived %d task(s)\n", len(archived))
+
+	return nil
+}
+
+func HandleArchive(ctx *cli.Context) error {
+	if ctx.Args().Get(0) == "" {
+		if !ctx.IsSet("older-than") {
+			return errors.New("Must provide task ID, status or --older-than")
+		}
+
+		return archiveByStatus(ctx, nil)
+	}
+
+	id, err := tasks.ParseID(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	if _, err := manager.Archive([]uint64{id}); err != nil {
+		return err
+	}
+
+	fmt.Println("Task archived successfully")
+	return nil
+}
+
+func HandleArchiveDone(ctx *cli.Context) error {
+	status := tasks.STATUS_DONE
+	return archiveByStatus(ctx, &status)
+}
+
+func HandleArchiveTodo(ctx *cli.Context) error {
+	status := tasks.STATUS_TODO
+	return archiveByStatus(ctx, &status)
+}
+
+func HandleArchiveInProgress(ctx *cli.Context) error {
+	status := tasks.STATUS_IN_PROGRESS
+	return archiveByStatus(ctx, &status)
+}
+
+func HandleUnarchive(ctx *cli.Context) error {
+	id, err := tasks.ParseID(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	if _, err := manager.Unarchive(id); err != nil {
+		return err
+	}
+
+	fmt.Println("Task unarchived successfully")
+	return nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+const (
+	DEFAULT_TERM_WIDTH = 80
+	MIN_COLUMN_WIDTH   = 12
+	COLUMN_GAP         = " | "
+)
+
+// Returns the width of the terminal, or of $COLUMNS when stdout isn't a
+// terminal. Returns false if neither is known
+func getTerminalWidth() (int, bool) {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width, true
+	}
+
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width, true
+	}
+
+	return 0, false
+}
+
+// Returns the width of the terminal, falling back to a sensible default
+func terminalWidth() int {
+	if width, ok := getTerminalWidth(); ok {
+		return width
+	}
+
+	return DEFAULT_TERM_WIDTH
+}
+
+// Breaks text into lines no wider than width, splitting words only when a
+// single word doesn't fit. Widths under 1 are taken as 1, since narrow
+// columns with long IDs can leave no room at all
+func wrapText(text string, width int) []string {
+	width = max(width, 1)
+
+	lines := []string{}
+	line := []rune{}
+
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = line[:0]
+		}
+
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = line[:0]
+			}
+
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+
+		line = append(line, w...)
+	}
+
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+
+	return lines
+}
+
+// Pads (or cuts) text to exactly width runes
+func padText(text string, width int) string {
+	r := []rune(text)
+	if len(r) > width {
+		return string(r[:width])
+	}
+
+	return text + strings.Repeat(" ", width-len(r))
+}
+
+func boardColumn(status tasks.TaskStatus, width int) []string {
+	count := manager.CountWithStatus(status)
+
+	header := fmt.Sprintf("%s (%d)", status, count)
+	if limit, ok := manager.WipLimit(status); ok {
+		header = fmt.Sprintf("%s (%d/%d)", status, count, limit.Max)
+	}
+
+	lines := []string{header, strings.Repeat("-", width)}
+	for _, id := range manager.IDs() {
+		task, _ := manager.Get(id)
+		if task.Status != status {
+			continue
+		}
+
+		prefix := fmt.Sprintf("#%d ", task.Id)
+		indent := strings.Repeat(" ", len(prefix))
+
+		for idx, line := range wrapText(task.Description, width-len(prefix)) {
+			if idx == 0 {
+				lines = append(lines, prefix+line)
+			} else {
+				lines = append(lines, indent+line)
+			}
+		}
+	}
+
+	return lines
+}
+
+func printBoard(width int) {
+	columns := len(tasks.Statuses)
+
+	colWidth := (width - len(COLUMN_GAP)*(columns-1)) / columns
+	if colWidth < MIN_COLUMN_WIDTH {
+		colWidth = MIN_COLUMN_WIDTH
+	}
+
+	cols := make([][]string, columns)
+	height := 0
+	for idx, status := range tasks.Statuses {
+		cols[idx] = boardColumn(status, colWidth)
+		height = max(height, len(cols[idx]))
+	}
+
+	for row := range height {
+		cells := make([]string, columns)
+		for idx, col := range cols {
+			if row < len(col) {
+				cells[idx] = padText(col[row], colWidth)
+			} else {
+				cells[idx] = padText("", colWidth)
+			}
+
+			if row == 0 {
+				cells[idx] = palette.Paint(statusRole(tasks.Statuses[idx]), cells[idx])
+			}
+		}
+
+		fmt.Println(strings.TrimRight(strings.Join(cells, COLUMN_GAP), " "))
+	}
+}
+
+func HandleBoard(ctx *cli.Context) error {
+	width := ctx.Int("width")
+	if width <= 0 {
+		width = terminalWidth()
+	}
+
+	printBoard(width)
+	return nil
+}
+
+func HandleWip(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		hasLimit := false
+		for _, status := range tasks.Statuses {
+			if limit, ok := manager.WipLimit(status); ok {
+				mode := "refuse"
+				if limit.WarnOnly {
+					mode = "warn"
+				}
+
+				fmt.Printf("%-12s %-4d (%s)\n", status, limit.Max, mode)
+				hasLimit = true
+			}
+		}
+
+		if !hasLimit {
+			fmt.Println("There are no WIP limits set!")
+		}
+
+		return nil
+	}
+
+	if ctx.NArg() != 2 {
+		return errors.New("Must provide a status and a limit")
+	}
+
+	status, err := tasks.ParseStatus(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	maxTasks, err := strconv.Atoi(ctx.Args().Get(1))
+	if err != nil || maxTasks < 0 {
+		return fmt.Errorf("'%s' is not a valid limit!", ctx.Args().Get(1))
+	}
+
+	manager.SetWipLimit(status, tasks.WipLimit{Max: maxTasks, WarnOnly: ctx.Bool("warn-only")})
+	if maxTasks == 0 {
+		fmt.Printf("Removed WIP limit for '%s'\n", status)
+		return nil
+	}
+
+	fmt.Printf("Set WIP limit for '%s' to %d\n", status, maxTasks)
+
+	return nil
+}
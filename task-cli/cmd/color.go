@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// When to colour the output
+const (
+	COLOR_AUTO   = "auto"
+	COLOR_ALWAYS = "always"
+	COLOR_NEVER  = "never"
+
+	// See https://no-color.org
+	NO_COLOR_ENV = "NO_COLOR"
+)
+
+var ColorModes []string = []string{COLOR_AUTO, COLOR_ALWAYS, COLOR_NEVER}
+
+// Parts of the output that can be coloured
+const (
+	ROLE_TODO        = "todo"
+	ROLE_IN_PROGRESS = "in-progress"
+	ROLE_DONE        = "done"
+	ROLE_OVERDUE     = "overdue"
+	ROLE_HEADER      = "header"
+
+	// Rows that changed while watching a list
+	ROLE_CHANGED = "changed"
+)
+
+var ColorRoles []string = []string{ROLE_TODO, ROLE_IN_PROGRESS, ROLE_DONE, ROLE_OVERDUE, ROLE_HEADER, ROLE_CHANGED}
+
+// Styles for each role, as understood by parseStyle
+var Themes map[string]map[string]string = map[string]map[string]string{
+	"default": {
+		ROLE_IN_PROGRESS: "yellow",
+		ROLE_DONE:        "grey",
+		ROLE_OVERDUE:     "bold red",
+		ROLE_HEADER:      "bold",
+		ROLE_CHANGED:     "reverse",
+	},
+	// For terminals with a light background, where yellow is hard to read
+	"light": {
+		ROLE_IN_PROGRESS: "blue",
+		ROLE_DONE:        "grey",
+		ROLE_OVERDUE:     "bold red",
+		ROLE_HEADER:      "bold",
+		ROLE_CHANGED:     "reverse",
+	},
+	"vivid": {
+		ROLE_TODO:        "cyan",
+		ROLE_IN_PROGRESS: "bold yellow",
+		ROLE_DONE:        "green",
+		ROLE_OVERDUE:     "bold white on red",
+		ROLE_HEADER:      "bold underline",
+		ROLE_CHANGED:     "black on yellow",
+	},
+	// No colours at all, only emphasis
+	"mono": {
+		ROLE_IN_PROGRESS: "bold",
+		ROLE_DONE:        "dim",
+		ROLE_OVERDUE:     "underline",
+		ROLE_HEADER:      "bold",
+		ROLE_CHANGED:     "reverse",
+	},
+}
+
+func getThemeNames() []string {
+	return slices.Sorted(maps.Keys(Themes))
+}
+
+var styleAttributes map[string]string = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+	"reverse":   "7",
+}
+
+var styleColors map[string]int = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3,
+	"blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+}
+
+// Parses a colour, as a name ("red", "bright-red", "grey") or as a number in
+// the 256 colour palette, into the SGR parameters for it
+func parseColor(color string, background bool) (string, error) {
+	base := 30
+	if background {
+		base = 40
+	}
+
+	if color == "grey" || color == "gray" {
+		color = "bright-black"
+	}
+
+	if name, ok := strings.CutPrefix(color, "bright-"); ok {
+		if n, ok := styleColors[name]; ok {
+			return strconv.Itoa(base + 60 + n), nil
+		}
+	}
+
+	if n, ok := styleColors[color]; ok {
+		return strconv.Itoa(base + n), nil
+	}
+
+	if n, err := strconv.Atoi(color); err == nil && n >= 0 && n <= 255 {
+		return fmt.Sprintf("%d;5;%d", base+8, n), nil
+	}
+
+	return "", fmt.Errorf("'%s' is not a colour!", color)
+}
+
+// Parses a style like "bold red", "yellow on blue" or "underline 208" into
+// the SGR parameters for it. An empty style is no style at all
+func parseStyle(style string) (string, error) {
+	params := []string{}
+
+	words := strings.Fields(strings.ToLower(style))
+	for idx := 0; idx < len(words); idx++ {
+		word := words[idx]
+
+		if attr, ok := styleAttributes[word]; ok {
+			params = append(params, attr)
+			continue
+		}
+
+		background := false
+		if word == "on" {
+			if idx+1 == len(words) {
+				return "", fmt.Errorf("'%s' is missing a background colour!", style)
+			}
+
+			idx++
+			word = words[idx]
+			background = true
+		}
+
+		param, err := parseColor(word, background)
+		if err != nil {
+			return "", err
+		}
+
+		params = append(params, param)
+	}
+
+	return strings.Join(params, ";"), nil
+}
+
+// The styles output is coloured with
+type Palette struct {
+	Enabled bool
+	styles  map[string]string
+}
+
+var palette Palette = Palette{}
+
+// Wraps text in a role's style, if colour is enabled
+func (p Palette) Paint(role, text string) string {
+	style := p.styles[role]
+	if !p.Enabled || style == "" {
+		return text
+	}
+
+	return "\x1b[" + style + "m" + text + "\x1b[0m"
+}
+
+// Returns the role of a task's status
+func statusRole(status tasks.TaskStatus) string {
+	switch status {
+	case tasks.STATUS_IN_PROGRESS:
+		return ROLE_IN_PROGRESS
+	case tasks.STATUS_DONE:
+		return ROLE_DONE
+	default:
+		return ROLE_TODO
+	}
+}
+
+// Returns whether output should be coloured, going by --color (or the
+// color.mode setting), $NO_COLOR and whether stdout is a terminal
+func useColor(mode string) bool {
+	switch mode {
+	case COLOR_ALWAYS:
+		return true
+	case COLOR_NEVER:
+		return false
+	}
+
+	if os.Getenv(NO_COLOR_ENV) != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Sets up the palette from the flags and settings
+//
+// Roles can be restyled one by one (color.done and such), on top of the theme
+func loadPalette(ctx *cli.Context) error {
+	mode := getStringSetting(ctx, "color", "color.mode")
+	if !slices.Contains(ColorModes, mode) {
+		return fmt.Errorf("'%s' is not a colour mode (must be auto, always or never)", mode)
+	}
+
+	theme := Themes[cfg.String("color.theme")]
+
+	styles := map[string]string{}
+	for _, role := range ColorRoles {
+		style := theme[role]
+		if custom := cfg.String("color." + role); custom != "" {
+			style = custom
+		}
+
+		params, err := parseStyle(style)
+		if err != nil {
+			return fmt.Errorf("Bad style for '%s': %v", role, err)
+		}
+
+		styles[role] = params
+	}
+
+	palette = Palette{Enabled: useColor(mode), styles: styles}
+	return nil
+}
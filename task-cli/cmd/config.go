@@ -0,0 +1,440 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	CONFIG_ENV = "TASK_CLI_CONFIG"
+
+	// Settings can be overridden by environment variables named after them,
+	// like TASK_CLI_LIST_VERBOSE for list.verbose
+	SETTING_ENV_PREFIX = "TASK_CLI_"
+)
+
+// Where a setting's value came from
+const (
+	SOURCE_DEFAULT = "default"
+	SOURCE_CONFIG  = "config"
+	SOURCE_ENV     = "env"
+)
+
+type SettingKind int
+
+const (
+	SETTING_STRING SettingKind = iota
+	SETTING_INT
+	SETTING_BOOL
+
+	// A style for coloured output, like "bold red"
+	SETTING_STYLE
+)
+
+// Something that can be configured
+type Setting struct {
+	Key     string
+	Kind    SettingKind
+	Default string
+	Usage   string
+}
+
+var Settings []Setting = []Setting{
+	{"db.path", SETTING_STRING, DB_NAME, "Where the task database is (--db)"},
+	{"list.verbose", SETTING_BOOL, "false", "Whether lists are verbose by default (--verbose)"},
+	{"list.desc-width", SETTING_INT, "48", "Width of the description column"},
+	{"list.status-width", SETTING_INT, "12", "Width of the status column"},
+	{"date.format", SETTING_STRING, "2006-01-02 15:04:05", "Format of dates and times, as a Go layout"},
+	{"actor", SETTING_STRING, "", "Who changes are recorded as (defaults to $USER)"},
+	{"templates.dir", SETTING_STRING, "", "Directory templates are kept in (--dir)"},
+	{"hooks.dir", SETTING_STRING, "", "Directory hooks are kept in"},
+	{"color.mode", SETTING_STRING, COLOR_AUTO, "When to colour the output: auto, always or never (--color)"},
+	{"color.theme", SETTING_STRING, "default", "Colour theme: default, light, vivid or mono"},
+	{"color.todo", SETTING_STYLE, "", "Style of to-do tasks (overrides the theme)"},
+	{"color.in-progress", SETTING_STYLE, "", "Style of in-progress tasks (overrides the theme)"},
+	{"color.done", SETTING_STYLE, "", "Style of done tasks (overrides the theme)"},
+	{"color.overdue", SETTING_STYLE, "", "Style of overdue tasks (overrides the theme)"},
+	{"color.header", SETTING_STYLE, "", "Style of headers (overrides the theme)"},
+	{"color.changed", SETTING_STYLE, "", "Style of rows that changed, when watching (overrides the theme)"},
+}
+
+// Returns the values a string setting is limited to, if it is
+func getSettingChoices(key string) []string {
+	switch key {
+	case "color.mode":
+		return ColorModes
+	case "color.theme":
+		return getThemeNames()
+	default:
+		return nil
+	}
+}
+
+func findSetting(key string) (Setting, error) {
+	idx := slices.IndexFunc(Settings, func(s Setting) bool {
+		return s.Key == key
+	})
+
+	if idx < 0 {
+		return Setting{}, fmt.Errorf("Unknown setting '%s'!", key)
+	}
+
+	return Settings[idx], nil
+}
+
+func (s Setting) Env() string {
+	return SETTING_ENV_PREFIX + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(s.Key))
+}
+
+// Checks a value, returning it typed as it's written to the config file
+func (s Setting) parse(value string) (any, error) {
+	switch s.Kind {
+	case SETTING_INT:
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("'%s' must be a positive number, not '%s'!", s.Key, value)
+		}
+
+		return n, nil
+	case SETTING_BOOL:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' must be true or false, not '%s'!", s.Key, value)
+		}
+
+		return b, nil
+	case SETTING_STYLE:
+		if _, err := parseStyle(value); err != nil {
+			return nil, fmt.Errorf("'%s' must be a style like \"bold red\": %v", s.Key, err)
+		}
+
+		return value, nil
+	default:
+		if choices := getSettingChoices(s.Key); choices != nil && !slices.Contains(choices, value) {
+			return nil, fmt.Errorf("'%s' must be one of %s, not '%s'!", s.Key, strings.Join(choices, ", "), value)
+		}
+
+		return value, nil
+	}
+}
+
+// The settings in the config file
+//
+// Values are looked up in the environment first, then in the file, and then
+// fall back to their defaults. Flags are handled by the commands themselves,
+// since they come first
+type Config struct {
+	Path   string
+	values map[string]string
+
+	// Keys in the file that aren't settings, kept as they were
+	Unknown []string
+	unknown map[string]any
+
+	// Why values in the file were ignored. They're kept as they were too
+	// (along with the unknown keys), until they're set or unset
+	Invalid []error
+}
+
+func NewConfig(path string) *Config {
+	return &Config{Path: path, values: map[string]string{}, unknown: map[string]any{}}
+}
+
+// Flattens TOML tables into dotted keys
+func flattenConfig(prefix string, table map[string]any, values map[string]any) {
+	for key, value := range table {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if sub, ok := value.(map[string]any); ok {
+			flattenConfig(key, sub, values)
+		} else {
+			values[key] = value
+		}
+	}
+}
+
+// Reads a config file. A missing file is the same as an empty one
+//
+// Bad values don't stop it from loading, so that they can still be fixed with
+// config set. The settings they're for are left at their defaults instead
+func LoadConfig(path string) (*Config, error) {
+	config := NewConfig(path)
+
+	table := map[string]any{}
+	if _, err := toml.DecodeFile(path, &table); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return config, nil
+		}
+
+		return nil, fmt.Errorf("Error reading config file '%s': %v", path, err)
+	}
+
+	values := map[string]any{}
+	flattenConfig("", table, values)
+
+	for key, value := range values {
+		s, err := findSetting(key)
+		if err != nil {
+			config.Unknown = append(config.Unknown, key)
+			config.unknown[key] = value
+			continue
+		}
+
+		str := fmt.Sprint(value)
+		if _, err := s.parse(str); err != nil {
+			config.Invalid = append(config.Invalid, err)
+			config.unknown[key] = value
+			continue
+		}
+
+		config.values[key] = str
+	}
+
+	slices.Sort(config.Unknown)
+	slices.SortFunc(config.Invalid, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+
+	return config, nil
+}
+
+// Returns a setting's value, and where it came from
+func (c *Config) Lookup(key string) (string, string) {
+	s, err := findSetting(key)
+	if err != nil {
+		return "", SOURCE_DEFAULT
+	}
+
+	// Bad values were warned about when loading
+	if value, ok := os.LookupEnv(s.Env()); ok {
+		if _, err := s.parse(value); err == nil {
+			return value, SOURCE_ENV
+		}
+	}
+
+	if value, ok := c.values[key]; ok {
+		return value, SOURCE_CONFIG
+	}
+
+	return s.Default, SOURCE_DEFAULT
+}
+
+func (c *Config) String(key string) string {
+	value, _ := c.Lookup(key)
+	return value
+}
+
+func (c *Config) Int(key string) int {
+	n, _ := strconv.Atoi(c.String(key))
+	return n
+}
+
+func (c *Config) Bool(key string) bool {
+	b, _ := strconv.ParseBool(c.String(key))
+	return b
+}
+
+// Sets a value in the file (once saved)
+func (c *Config) Set(key, value string) error {
+	s, err := findSetting(key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.parse(value); err != nil {
+		return err
+	}
+
+	c.values[key] = value
+	delete(c.unknown, key)
+	return nil
+}
+
+// Removes a value from the file (once saved), so it goes back to its default
+func (c *Config) Unset(key string) error {
+	if _, err := findSetting(key); err != nil {
+		return err
+	}
+
+	delete(c.values, key)
+	delete(c.unknown, key)
+	return nil
+}
+
+// Writes the config file
+func (c *Config) Save() error {
+	values := maps.Clone(c.unknown)
+	for key, value := range c.values {
+		s, _ := findSetting(key)
+		values[key], _ = s.parse(value)
+	}
+
+	table := map[string]any{}
+	for key, typed := range values {
+		// Dotted keys become tables
+		parts := strings.Split(key, ".")
+		parent := table
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := parent[part].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				parent[part] = sub
+			}
+
+			parent = sub
+		}
+
+		parent[parts[len(parts)-1]] = typed
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(c.Path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	return toml.NewEncoder(file).Encode(table)
+}
+
+var cfg *Config = NewConfig("")
+
+// Returns where the config file is: --config, $TASK_CLI_CONFIG, or
+// ~/.config/task-cli/config.toml
+func getConfigPath(ctx *cli.Context) (string, error) {
+	if path := ctx.String("config"); path != "" {
+		return path, nil
+	}
+
+	if path := os.Getenv(CONFIG_ENV); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "task-cli", "config.toml"), nil
+}
+
+func loadConfig(ctx *cli.Context) error {
+	path, err := getConfigPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range c.Unknown {
+		fmt.Fprintf(os.Stderr, "Warning! Unknown setting '%s' in '%s'\n", key, path)
+	}
+
+	for _, err := range c.Invalid {
+		fmt.Fprintf(os.Stderr, "Warning! Ignoring a value in '%s': %v\n", path, err)
+	}
+
+	for _, s := range Settings {
+		if value, ok := os.LookupEnv(s.Env()); ok {
+			if _, err := s.parse(value); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning! Ignoring $%s: %v\n", s.Env(), err)
+			}
+		}
+	}
+
+	cfg = c
+	return nil
+}
+
+// Returns a boolean flag's value if it was given, or else the setting's
+func getBoolSetting(ctx *cli.Context, flag, key string) bool {
+	if ctx.IsSet(flag) {
+		return ctx.Bool(flag)
+	}
+
+	return cfg.Bool(key)
+}
+
+// Returns a string flag's value if it was given, or else the setting's
+func getStringSetting(ctx *cli.Context, flag, key string) string {
+	if ctx.IsSet(flag) {
+		return ctx.String(flag)
+	}
+
+	return cfg.String(key)
+}
+
+func HandleConfigGet(ctx *cli.Context) error {
+	s, err := findSetting(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(cfg.String(s.Key))
+	return nil
+}
+
+func HandleConfigSet(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("Must provide a setting and its value")
+	}
+
+	key, value := ctx.Args().Get(0), ctx.Args().Get(1)
+	if err := cfg.Set(key, value); err != nil {
+		return err
+	}
+
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Set '%s' to '%s' in '%s'\n", key, value, cfg.Path)
+
+	if _, source := cfg.Lookup(key); source == SOURCE_ENV {
+		s, _ := findSetting(key)
+		fmt.Printf("Warning! $%s overrides it\n", s.Env())
+	}
+
+	return nil
+}
+
+func HandleConfigUnset(ctx *cli.Context) error {
+	key := ctx.Args().Get(0)
+	if err := cfg.Unset(key); err != nil {
+		return err
+	}
+
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Unset '%s' in '%s'\n", key, cfg.Path)
+	return nil
+}
+
+func HandleConfigList(ctx *cli.Context) error {
+	fmt.Printf("%-20s %-24s %-8s %s\n", "KEY", "VALUE", "FROM", "DESCRIPTION")
+	for _, s := range Settings {
+		value, source := cfg.Lookup(s.Key)
+		fmt.Printf("%-20s %-24s %-8s %s\n", s.Key, value, source, s.Usage)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+const (
+	PASSPHRASE_ENV = "TASK_CLI_PASSPHRASE"
+)
+
+// Reads the passphrase from the environment or, failing that, prompts for it
+//
+// When confirm is set, the passphrase has to be typed twice
+func getPassphrase(confirm bool) ([]byte, error) {
+	if pass := os.Getenv(PASSPHRASE_ENV); pass != "" {
+		return []byte(pass), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("No terminal to ask for a passphrase on (set $%s)", PASSPHRASE_ENV)
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pass) == 0 {
+		return nil, errors.New("The passphrase can't be empty!")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+
+		if string(again) != string(pass) {
+			return nil, errors.New("Passphrases don't match!")
+		}
+	}
+
+	return pass, nil
+}
+
+func HandleDBEncrypt(ctx *cli.Context) error {
+	if store.Encrypted() {
+		return errors.New("The database is already encrypted!")
+	}
+
+	pass, err := getPassphrase(true)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Encrypt(pass); err != nil {
+		return err
+	}
+
+	fmt.Println("Database encrypted successfully")
+	return nil
+}
+
+func HandleDBDecrypt(ctx *cli.Context) error {
+	if err := store.Decrypt(); err != nil {
+		return err
+	}
+
+	fmt.Println("Database decrypted successfully")
+	return nil
+}
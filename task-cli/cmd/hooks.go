@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+// Returns the directory hooks are kept in
+func getHooksDir() (string, error) {
+	if dir := cfg.String("hooks.dir"); dir != "" {
+		return dir, nil
+	}
+
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(config, "task-cli", "hooks"), nil
+}
+
+// Returns the hooks to run, or nil if they're disabled
+func getHooks(ctx *cli.Context) (tasks.Hooks, error) {
+	if ctx.Bool("no-hooks") {
+		return nil, nil
+	}
+
+	dir, err := getHooksDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks.HookDir{Path: dir, Output: os.Stdout}, nil
+}
+
+func HandleHookList(ctx *cli.Context) error {
+	dir, err := getHooksDir()
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	for _, event := range tasks.HookEvents {
+		hooks, err := tasks.HookDir{Path: dir}.Find(event)
+		if err != nil {
+			return err
+		}
+
+		for _, hook := range hooks {
+			fmt.Printf("%-10s %s\n", event, filepath.Base(hook))
+			found++
+		}
+	}
+
+	if found == 0 {
+		fmt.Printf("There are no hooks in '%s'!\n", dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+// Reads an estimate flag, if it was given
+func getEstimateFlag(ctx *cli.Context, name string) (*tasks.Estimate, error) {
+	if !ctx.IsSet(name) {
+		return nil, nil
+	}
+
+	estimate, err := tasks.ParseEstimate(ctx.String(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &estimate, nil
+}
+
+func getQueryOptions(ctx *cli.Context) (tasks.QueryOptions, error) {
+	opts := tasks.QueryOptions{
+		Archived:    ctx.Bool("archived"),
+		Unestimated: ctx.Bool("unestimated"),
+		SortBy:      ctx.String("sort"),
+		Descending:  ctx.Bool("desc"),
+		Limit:       ctx.Int("limit"),
+		Offset:      ctx.Int("offset"),
+	}
+
+	var err error
+	if opts.MinEstimate, err = getEstimateFlag(ctx, "min-estimate"); err != nil {
+		return opts, err
+	}
+
+	if opts.MaxEstimate, err = getEstimateFlag(ctx, "max-estimate"); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// Prints a task's row, marking it if it changed
+func printRow(task tasks.Task, verbose bool, changed map[uint64]bool) {
+	row := formatTask(task, verbose)
+
+	if changed[task.Id] {
+		id := fmt.Sprintf("%-4d", task.Id)
+		row = palette.Paint(ROLE_CHANGED, id) + row[len(id):]
+
+		// Without colours, a star has to do
+		if !palette.Enabled {
+			first, rest, _ := strings.Cut(row, "\n")
+			row = strings.TrimRight(first, " ") + " *"
+			if rest != "" {
+				row += "\n" + rest
+			}
+		}
+	}
+
+	fmt.Println(row)
+}
+
+// Prints a list of tasks, optionally grouped. Tasks whose IDs are in changed
+// are highlighted
+func printTasks(list []tasks.Task, verbose bool, groupBy string, changed map[uint64]bool) error {
+	if _, ok := tasks.Groupers[groupBy]; groupBy != "" && !ok {
+		return fmt.Errorf("Can't group by '%s' (must be status, tag, project or due-week)", groupBy)
+	}
+
+	if len(list) == 0 {
+		printListHeader(verbose)
+		fmt.Println("There are no tasks to display!")
+		return nil
+	}
+
+	if groupBy == "" {
+		printListHeader(verbose)
+		for _, task := range list {
+			printRow(task, verbose, changed)
+		}
+
+		return nil
+	}
+
+	groups, err := tasks.GroupTasks(list, groupBy)
+	if err != nil {
+		return err
+	}
+
+	printListHeader(verbose)
+	for idx, group := range groups {
+		if idx > 0 {
+			fmt.Println()
+		}
+
+		fmt.Println(palette.Paint(ROLE_HEADER, fmt.Sprintf("== %s (%d) ==", group.Name, len(group.Tasks))))
+		for _, task := range group.Tasks {
+			printRow(task, verbose, changed)
+		}
+	}
+
+	return nil
+}
+
+func listWithStatus(ctx *cli.Context, status *tasks.TaskStatus) error {
+	opts, err := getQueryOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts.Status = status
+
+	if ctx.Bool("watch") {
+		return watchList(ctx, opts)
+	}
+
+	list, err := manager.Query(opts)
+	if err != nil {
+		return err
+	}
+
+	return printTasks(list, getBoolSetting(ctx, "verbose", "list.verbose"), ctx.String("group-by"), nil)
+}
+
+func HandleList(ctx *cli.Context) error {
+	return listWithStatus(ctx, nil)
+}
+
+func HandleListDone(ctx *cli.Context) error {
+	status := tasks.STATUS_DONE
+	return listWithStatus(ctx, &status)
+}
+
+func HandleListTodo(ctx *cli.Context) error {
+	status := tasks.STATUS_TODO
+	return listWithStatus(ctx, &status)
+}
+
+func HandleListInProgress(ctx *cli.Context) error {
+	status := tasks.STATUS_IN_PROGRESS
+	return listWithStatus(ctx, &status)
+}
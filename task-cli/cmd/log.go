@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+func HandleLog(ctx *cli.Context) error {
+	id, err := tasks.ParseID(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	// Archived tasks keep their history too
+	task, err := manager.Get(id)
+	if errors.Is(err, tasks.ErrNotFound) {
+		archived, archivedErr := manager.GetArchived(id)
+		if archivedErr != nil {
+			return err
+		}
+
+		task = archived
+	} else if err != nil {
+		return err
+	}
+
+	fmt.Printf("History of task %d ('%s'):\n", task.Id, task.Description)
+	if task.Source != nil {
+		fmt.Printf("From %s at %s\n", task.Source.Kind, sourceLocation(task.Source))
+	}
+
+	if len(task.History) == 0 {
+		fmt.Println("There's no history to display!")
+		return nil
+	}
+
+	for _, change := range task.History {
+		actor := change.Actor
+		if actor == "" {
+			actor = "-"
+		}
+
+		fmt.Printf("%-*s %-12s %s\n", getDateWidth(), formatTime(change.At), actor, change)
+	}
+
+	return nil
+}
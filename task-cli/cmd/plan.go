@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+// Formats a duration as hours and minutes, like "3h05m"
+func formatTracked(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func printEstimateTotals() {
+	fmt.Println(palette.Paint(ROLE_HEADER, fmt.Sprintf("%-12s %-6s %-10s %-10s %s", "STATUS", "TASKS", "ESTIMATE", "POINTS", "TRACKED")))
+
+	for _, t := range manager.EstimateTotals() {
+		hours := tasks.Estimate{Amount: t.Hours, Unit: tasks.UNIT_HOURS}
+		points := tasks.Estimate{Amount: t.Points, Unit: tasks.UNIT_POINTS}
+
+		fmt.Printf(
+			"%s %-6d %-10s %-10s %s\n", palette.Paint(statusRole(t.Status), fmt.Sprintf("%-12s", t.Status)),
+			t.Count, hours, points, formatTracked(t.Tracked),
+		)
+	}
+}
+
+func HandlePlan(ctx *cli.Context) error {
+	if !ctx.IsSet("capacity") {
+		printEstimateTotals()
+		return nil
+	}
+
+	capacity, err := tasks.ParseEstimate(ctx.String("capacity"))
+	if err != nil {
+		return err
+	}
+
+	if capacity.Amount <= 0 {
+		return errors.New("The capacity must be more than 0!")
+	}
+
+	plan := manager.Plan(capacity)
+
+	if len(plan.Tasks) == 0 {
+		fmt.Printf("No to-do tasks fit in %s!\n", capacity)
+	} else {
+		fmt.Printf("Plan for %s:\n", capacity)
+		for _, task := range plan.Tasks {
+			fmt.Printf("  %-4d %-*s %-8s %s\n", task.Id, cfg.Int("list.desc-width"), task.Description, task.Estimate, task.Priority)
+		}
+
+		fmt.Printf("Total: %s of %s\n", tasks.Estimate{Amount: plan.Total, Unit: capacity.Unit}, capacity)
+	}
+
+	if len(plan.Unestimated) > 0 {
+		fmt.Printf("%d unblocked to-do task(s) weren't estimated in the same unit as the capacity, and were left out\n", len(plan.Unestimated))
+	}
+
+	fmt.Println()
+	printEstimateTotals()
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+// Returns where a task's comment is, relative to the working directory when
+// it's inside it
+func sourceLocation(source *tasks.SourceRef) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return source.Location()
+	}
+
+	rel, err := filepath.Rel(wd, source.File)
+	if err != nil || !filepath.IsAbs(source.File) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return source.Location()
+	}
+
+	return fmt.Sprintf("%s:%d", rel, source.Line)
+}
+
+func printScanned(action string, affected []tasks.Task) {
+	if len(affected) == 0 {
+		return
+	}
+
+	fmt.Printf("%s %d task(s):\n", action, len(affected))
+	for _, task := range affected {
+		fmt.Printf("  %-4d %-*s %s\n", task.Id, cfg.Int("list.desc-width"), task.Description, sourceLocation(task.Source))
+	}
+}
+
+func HandleScan(ctx *cli.Context) error {
+	root := ctx.Args().Get(0)
+	if root == "" {
+		root = "."
+	}
+
+	found, err := tasks.ScanDir(root)
+	if err != nil {
+		return err
+	}
+
+	result, err := manager.SyncComments(root, found)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Found %d comment(s) in '%s'\n", len(found), root)
+
+	printScanned("Added", result.Added)
+	printScanned("Moved", result.Moved)
+	printScanned("Closed", result.Closed)
+
+	if result.Unchanged > 0 {
+		fmt.Printf("%d task(s) unchanged\n", result.Unchanged)
+	}
+
+	return nil
+}
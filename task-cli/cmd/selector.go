@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+// Returns whether any query flag (--status, --tag) was given
+func hasQuery(ctx *cli.Context) bool {
+	return ctx.IsSet("status") || ctx.IsSet("tag")
+}
+
+// Builds a selector from an ID list (may be empty) and the query flags
+func getSelector(ctx *cli.Context, idList string) (tasks.Selector, error) {
+	sel := tasks.Selector{}
+
+	if idList == "" && !hasQuery(ctx) {
+		return sel, errors.New("Must provide task IDs or a query (--status, --tag)")
+	}
+
+	if idList != "" {
+		ids, err := tasks.ParseIDList(idList)
+		if err != nil {
+			return sel, err
+		}
+
+		sel.IDs = ids
+	}
+
+	if ctx.IsSet("status") {
+		status, err := tasks.ParseStatus(ctx.String("status"))
+		if err != nil {
+			return sel, err
+		}
+
+		sel.Status = &status
+	}
+
+	sel.Tags = ctx.StringSlice("tag")
+	return sel, nil
+}
+
+// Resolves the ID list and query flags into task IDs
+func selectTasks(ctx *cli.Context, idList string) ([]uint64, error) {
+	sel, err := getSelector(ctx, idList)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.Select(sel)
+}
+
+func printAffected(action string, affected []tasks.Task) {
+	fmt.Printf("%s %d task(s):\n", action, len(affected))
+	for _, task := range affected {
+		fmt.Printf("  %-4d %s\n", task.Id, task.Description)
+	}
+}
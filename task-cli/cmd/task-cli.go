@@ -1,221 +1,209 @@
 package cmd
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"slices"
-	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/pbnjk/backend/task-cli/tasks"
 	"github.com/urfave/cli/v2"
 )
 
-type TaskStatus int
-
 const (
-	STATUS_TODO        TaskStatus = 0
-	STATUS_IN_PROGRESS TaskStatus = 1
-	STATUS_DONE        TaskStatus = 2
-)
+	DB_NAME = "db.json"
 
-func (s TaskStatus) String() string {
-	switch s {
-	case STATUS_TODO:
-		return "To-do"
-	case STATUS_IN_PROGRESS:
-		return "In Progress"
-	case STATUS_DONE:
-		return "Done"
-	default:
-		return "???"
-	}
-}
+	// Descriptions never get narrower than this, even on tiny terminals
+	MIN_DESC_WIDTH = 16
+)
 
-const (
-	DB_NAME = "db.json"
+var (
+	store   *tasks.FileStore = nil
+	manager *tasks.Manager   = nil
 )
 
-type Task struct {
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	Description string     `json:"desc"`
-	Id          uint64     `json:"id"`
-	Status      TaskStatus `json:"status"`
+// Formats a date and time as configured
+func formatTime(t time.Time) string {
+	return t.Format(cfg.String("date.format"))
 }
 
-func createTask(id uint64, desc string) Task {
-	return Task{
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-		Description: desc,
-		Id:          id,
-		Status:      STATUS_TODO,
-	}
+// Returns the width of the date columns, which depends on the date format
+func getDateWidth() int {
+	return max(len(formatTime(time.Now())), len("CREATED AT"))
 }
 
-func (t Task) String(verbose bool) string {
+// Returns the width of the description column, and whether descriptions
+// should wrap
+//
+// On a terminal (or with $COLUMNS set), the column shrinks so that rows fit
+// in it, and longer descriptions wrap. Elsewhere they're left whole
+func getDescWidth(verbose bool) (int, bool) {
+	width := cfg.Int("list.desc-width")
+
+	termWidth, ok := getTerminalWidth()
+	if !ok {
+		return width, false
+	}
+
+	// The ID column, and then the status (and date) columns
+	rest := 5 + 1 + len(tasks.STATUS_IN_PROGRESS.String())
 	if verbose {
-		return fmt.Sprintf(
-			"%-4d %-48s %-12s %-20s %-20s", t.Id, t.Description, t.Status.String(),
-			t.CreatedAt.Format("2006-01-02 15:04:05"), t.CreatedAt.Format("2006-01-02 15:04:05"),
-		)
-	} else {
-		return fmt.Sprintf("%-4d %-48s %s", t.Id, t.Description, t.Status.String())
+		rest = 5 + 1 + cfg.Int("list.status-width") + 2*(1+getDateWidth())
 	}
-}
 
-type Tasks struct {
-	Tasks map[uint64]Task `json:"tasks"`
+	return max(MIN_DESC_WIDTH, min(width, termWidth-rest)), true
 }
 
-var tasks *Tasks = nil
+func formatTask(t tasks.Task, verbose bool) string {
+	descWidth, wrap := getDescWidth(verbose)
 
-func getSortedTasksIDs() []uint64 {
-	ids := make([]uint64, 0, len(tasks.Tasks))
-	for k := range tasks.Tasks {
-		ids = append(ids, k)
+	desc := []string{t.Description}
+	if wrap {
+		desc = wrapText(t.Description, descWidth)
 	}
 
-	slices.Sort(ids)
-	return ids
-}
-
-func (t *Tasks) addTask(desc string) {
-	ids := getSortedTasksIDs()
+	descRole := ""
+	if t.IsOverdue(manager.Now()) {
+		descRole = ROLE_OVERDUE
+	}
 
-	id := uint64(1)
-	lastIdx := len(ids) - 1
+	// Padding is added around the colours, which %-*s would count as text
+	padding := strings.Repeat(" ", max(0, descWidth-utf8.RuneCountInString(desc[0])))
+	row := fmt.Sprintf("%-4d ", t.Id) + palette.Paint(descRole, desc[0]) + padding + " "
 
-	for cidx, cid := range ids {
-		if cid > 1 {
-			if _, ok := t.Tasks[cid-1]; !ok {
-				id = cid - 1
-				break
-			}
-		}
+	if verbose {
+		statusWidth, dateWidth := cfg.Int("list.status-width"), getDateWidth()
+		row += palette.Paint(statusRole(t.Status), fmt.Sprintf("%-*s", statusWidth, t.Status)) +
+			fmt.Sprintf(" %-*s %-*s", dateWidth, formatTime(t.CreatedAt), dateWidth, formatTime(t.UpdatedAt))
+	} else {
+		row += palette.Paint(statusRole(t.Status), t.Status.String())
+	}
 
-		if cidx == lastIdx || cid+1 != ids[cidx+1] {
-			id = cid + 1
-			break
-		}
+	for _, line := range desc[1:] {
+		row += "\n" + strings.Repeat(" ", 5) + palette.Paint(descRole, line)
 	}
 
-	tasks.Tasks[id] = createTask(id, desc)
-	fmt.Printf("Task added successfully! ID: %v\n", id)
+	return row
 }
 
-func (t *Tasks) updateTask(id uint64, newDesc string) error {
-	if task, ok := t.Tasks[id]; ok {
-		task.Description = newDesc
-		task.UpdatedAt = time.Now()
-
-		t.Tasks[id] = task
+// Reads the --project, --priority, --due, --parent and --estimate flags into
+// a set of changes
+//
+// An empty --due or --estimate, or a --parent of 0, clears them
+func getTaskChanges(ctx *cli.Context) (tasks.TaskChanges, error) {
+	changes := tasks.TaskChanges{}
 
-		return nil
+	if ctx.IsSet("project") {
+		project := ctx.String("project")
+		changes.Project = &project
 	}
 
-	return fmt.Errorf("No task with ID %v!\n", id)
-}
+	if ctx.IsSet("priority") {
+		priority, err := tasks.ParsePriority(ctx.String("priority"))
+		if err != nil {
+			return changes, err
+		}
 
-func (t *Tasks) deleteTasksByID(id uint64) error {
-	if _, ok := t.Tasks[id]; !ok {
-		return fmt.Errorf("No task with ID %v!\n", id)
+		changes.Priority = &priority
 	}
 
-	delete(t.Tasks, id)
-	return nil
-}
+	if ctx.IsSet("due") {
+		due := time.Time{}
+		if ctx.String("due") != "" {
+			d, err := tasks.ParseDue(ctx.String("due"))
+			if err != nil {
+				return changes, err
+			}
 
-func (t *Tasks) deleteTasksByStatus(status TaskStatus) {
-	for k, v := range t.Tasks {
-		if v.Status == status {
-			delete(t.Tasks, k)
+			due = d
 		}
-	}
-}
 
-func (t *Tasks) markTaskAs(id uint64, status TaskStatus) error {
-	if task, ok := t.Tasks[id]; ok {
-		task.Status = status
-		task.UpdatedAt = time.Now()
-
-		t.Tasks[id] = task
-
-		return nil
+		changes.Due = &due
 	}
 
-	return fmt.Errorf("No task with ID %v!\n", id)
-}
-
-func (t *Tasks) listByStatus(verbose bool, status TaskStatus) {
-	printListHeader(verbose)
-
-	hasTask := false
+	if ctx.IsSet("parent") {
+		parent := ctx.Uint64("parent")
+		changes.Parent = &parent
+	}
 
-	ids := getSortedTasksIDs()
-	for _, id := range ids {
-		task := t.Tasks[id]
+	if ctx.IsSet("estimate") {
+		estimate := tasks.Estimate{}
+		if ctx.String("estimate") != "" {
+			e, err := tasks.ParseEstimate(ctx.String("estimate"))
+			if err != nil {
+				return changes, err
+			}
 
-		if task.Status == status {
-			fmt.Println(task.String(verbose))
-			hasTask = true
+			estimate = e
 		}
-	}
 
-	if !hasTask {
-		fmt.Println("There are no tasks to display!")
+		changes.Estimate = &estimate
 	}
+
+	return changes, nil
 }
 
-// Loads a saved JSON database, if it exists
+// Loads the config file, and then the saved JSON database, if it exists
+//
+// The config commands don't need the database, so it isn't loaded for them
 func Load(ctx *cli.Context) error {
-	tasks = &Tasks{
-		Tasks: make(map[uint64]Task, 0),
-	}
-
-	file, err := os.ReadFile(DB_NAME)
-	if err != nil && errors.Is(err, os.ErrNotExist) {
-		return nil
+	if err := loadConfig(ctx); err != nil {
+		return err
 	}
 
-	if err := json.Unmarshal(file, tasks); err != nil {
-		return fmt.Errorf("Error unmarshalling JSON data: %v\n", err)
+	if err := loadPalette(ctx); err != nil {
+		return err
 	}
 
-	return nil
-}
-
-// Saves all tasks to a JSON file
-func Save(ctx *cli.Context) error {
-	if tasks == nil {
+	if ctx.Args().First() == "config" {
 		return nil
 	}
 
-	data, err := json.Marshal(tasks)
+	store = tasks.NewFileStore(getStringSetting(ctx, "db", "db.path"), getPassphrase)
+
+	m, err := tasks.NewManager(store, tasks.SystemClock{})
 	if err != nil {
-		return fmt.Errorf("Error marshalling JSON data: %v\n", err)
+		return err
 	}
 
-	if err := os.WriteFile(DB_NAME, data, 0644); err != nil {
+	m.SetActor(getActor())
+
+	hooks, err := getHooks(ctx)
+	if err != nil {
 		return err
 	}
 
+	m.SetHooks(hooks)
+
+	manager = m
 	return nil
 }
 
-func getIdFromString(id string) (uint64, error) {
-	if id == "" {
-		return 0, errors.New("Must provide task ID")
+// Returns who's running the tool, for the tasks' history
+func getActor() string {
+	if actor := cfg.String("actor"); actor != "" {
+		return actor
 	}
 
-	idNum, err := strconv.Atoi(id)
-	if err != nil {
-		return 0, fmt.Errorf("Couldn't convert ID '%v' to number!", id)
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+
+	return "unknown"
+}
+
+// Saves all tasks to a JSON file, readable only by its owner
+//
+// Save runs even after a failed load, in which case there's nothing to save
+func Save(ctx *cli.Context) error {
+	if manager == nil {
+		return nil
 	}
 
-	return uint64(idNum), nil
+	return manager.Save()
 }
 
 func HandleAdd(ctx *cli.Context) error {
@@ -223,119 +211,151 @@ func HandleAdd(ctx *cli.Context) error {
 		return errors.New("Must provide a task description")
 	}
 
-	tasks.addTask(ctx.Args().Get(0))
+	changes, err := getTaskChanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	task, err := manager.Add(ctx.Args().Get(0), ctx.StringSlice("tag"), changes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Task added successfully! ID: %v\n", task.Id)
 	return nil
 }
 
 func HandleUpdate(ctx *cli.Context) error {
-	desc := ctx.Args().Get(1)
-	if desc == "" {
-		return errors.New("Must provide task ID and updated description")
+	changes, err := getTaskChanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	idList, desc := ctx.Args().Get(0), ctx.Args().Get(1)
+	if ctx.NArg() == 1 && hasQuery(ctx) {
+		idList, desc = "", ctx.Args().Get(0)
+	}
+
+	if desc != "" {
+		changes.Description = &desc
+	}
+
+	if changes.IsEmpty() {
+		return errors.New("Must provide task IDs and updated description or fields")
 	}
 
-	id, err := getIdFromString(ctx.Args().Get(0))
+	ids, err := selectTasks(ctx, idList)
 	if err != nil {
 		return err
 	}
 
-	if err := tasks.updateTask(id, desc); err != nil {
+	updated, err := manager.Update(ids, changes)
+	if err != nil {
 		return err
 	}
 
+	printAffected("Updated", updated)
 	return nil
 }
 
 func HandleDelete(ctx *cli.Context) error {
-	id, err := getIdFromString(ctx.Args().Get(0))
+	ids, err := selectTasks(ctx, ctx.Args().Get(0))
 	if err != nil {
 		return err
 	}
 
-	if err := tasks.deleteTasksByID(id); err != nil {
+	deleted, err := manager.Delete(ids)
+	if err != nil {
 		return err
 	}
 
+	printAffected("Deleted", deleted)
 	return nil
 }
 
-func HandleDeleteDone(ctx *cli.Context) error {
-	tasks.deleteTasksByStatus(STATUS_DONE)
+func deleteWhere(status tasks.TaskStatus) error {
+	deleted, err := manager.DeleteWhere(status)
+	if err != nil {
+		return err
+	}
+
+	printAffected("Deleted", deleted)
 	return nil
 }
 
+func HandleDeleteDone(ctx *cli.Context) error {
+	return deleteWhere(tasks.STATUS_DONE)
+}
+
 func HandleDeleteTodo(ctx *cli.Context) error {
-	tasks.deleteTasksByStatus(STATUS_TODO)
-	return nil
+	return deleteWhere(tasks.STATUS_TODO)
 }
 
 func HandleDeleteInProgress(ctx *cli.Context) error {
-	tasks.deleteTasksByStatus(STATUS_IN_PROGRESS)
-	return nil
+	return deleteWhere(tasks.STATUS_IN_PROGRESS)
 }
 
-func HandleMarkInProgress(ctx *cli.Context) error {
-	id, err := getIdFromString(ctx.Args().Get(0))
-	if err != nil {
-		return err
-	}
+// Marks every selected task with the given status
+//
+// When selecting by query alone, tasks that already have the status are
+// skipped. Explicitly listed ones make the whole change fail instead
+func markSelectedAs(ctx *cli.Context, status tasks.TaskStatus) error {
+	idList := ctx.Args().Get(0)
 
-	if err := tasks.markTaskAs(id, STATUS_IN_PROGRESS); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func HandleMarkDone(ctx *cli.Context) error {
-	id, err := getIdFromString(ctx.Args().Get(0))
+	ids, err := selectTasks(ctx, idList)
 	if err != nil {
 		return err
 	}
 
-	if err := tasks.markTaskAs(id, STATUS_DONE); err != nil {
-		return err
-	}
-
-	return nil
-}
+	if idList == "" {
+		ids = slices.DeleteFunc(ids, func(id uint64) bool {
+			task, _ := manager.Get(id)
+			return task.Status == status
+		})
 
-func printListHeader(verbose bool) {
-	if verbose {
-		fmt.Printf("%-4s %-48s %-12s %-20s %-20s\n", "ID", "DESCRIPTION", "STATUS", "CREATED AT", "UPDATED AT")
-	} else {
-		fmt.Printf("%-4s %-48s %s\n", "ID", "DESCRIPTION", "STATUS")
+		if len(ids) == 0 {
+			return tasks.ErrNoMatch
+		}
 	}
-}
 
-func HandleList(ctx *cli.Context) error {
-	verbose := ctx.Bool("verbose")
+	force := ctx.Bool("force")
 
-	printListHeader(verbose)
+	var limitErr *tasks.WipLimitError
+	if err := manager.CheckWipLimit(status, ids); !force && errors.As(err, &limitErr) {
+		if !limitErr.Limit.WarnOnly {
+			return fmt.Errorf("%v (use --force to do it anyway)", err)
+		}
 
-	ids := getSortedTasksIDs()
-	if len(ids) == 0 {
-		fmt.Println("There are no tasks to display!")
-		return nil
+		fmt.Printf("Warning! This puts %d tasks in '%s', over the limit of %d!\n", limitErr.Count, status, limitErr.Limit.Max)
 	}
 
-	for _, id := range ids {
-		fmt.Println(tasks.Tasks[id].String(verbose))
+	marked, err := manager.Mark(ids, status, force)
+	if err != nil {
+		return err
 	}
 
+	printAffected(fmt.Sprintf("Marked as %s", status), marked)
 	return nil
 }
 
-func HandleListDone(ctx *cli.Context) error {
-	tasks.listByStatus(ctx.Bool("verbose"), STATUS_DONE)
-	return nil
+func HandleMarkInProgress(ctx *cli.Context) error {
+	return markSelectedAs(ctx, tasks.STATUS_IN_PROGRESS)
 }
 
-func HandleListTodo(ctx *cli.Context) error {
-	tasks.listByStatus(ctx.Bool("verbose"), STATUS_TODO)
-	return nil
+func HandleMarkDone(ctx *cli.Context) error {
+	return markSelectedAs(ctx, tasks.STATUS_DONE)
 }
 
-func HandleListInProgress(ctx *cli.Context) error {
-	tasks.listByStatus(ctx.Bool("verbose"), STATUS_IN_PROGRESS)
-	return nil
+func printListHeader(verbose bool) {
+	descWidth, _ := getDescWidth(verbose)
+
+	if verbose {
+		statusWidth, dateWidth := cfg.Int("list.status-width"), getDateWidth()
+		fmt.Println(palette.Paint(ROLE_HEADER, fmt.Sprintf(
+			"%-4s %-*s %-*s %-*s %-*s", "ID", descWidth, "DESCRIPTION", statusWidth, "STATUS",
+			dateWidth, "CREATED AT", dateWidth, "UPDATED AT",
+		)))
+	} else {
+		fmt.Println(palette.Paint(ROLE_HEADER, fmt.Sprintf("%-4s %-*s %s", "ID", descWidth, "DESCRIPTION", "STATUS")))
+	}
 }
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+)
+
+// Returns the directory templates are kept in
+func getTemplateDir(ctx *cli.Context) (string, error) {
+	if dir := getStringSetting(ctx, "dir", "templates.dir"); dir != "" {
+		return dir, nil
+	}
+
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(config, "task-cli", "templates"), nil
+}
+
+// Finds a template by name in the template directory, or by path
+func findTemplate(ctx *cli.Context, name string) (*tasks.Template, error) {
+	if name == "" {
+		return nil, errors.New("Must provide a template name")
+	}
+
+	if _, err := os.Stat(name); err == nil && filepath.Ext(name) != "" {
+		return tasks.LoadTemplate(name)
+	}
+
+	dir, err := getTemplateDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ext := range tasks.TemplateExtensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return tasks.LoadTemplate(path)
+		}
+	}
+
+	return nil, fmt.Errorf("No template named '%s' in '%s'!", name, dir)
+}
+
+// Parses --var name=value flags
+func getTemplateVars(ctx *cli.Context) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, v := range ctx.StringSlice("var") {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("'%s' is not a valid variable (must be name=value)", v)
+		}
+
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+func HandleApply(ctx *cli.Context) error {
+	tmpl, err := findTemplate(ctx, ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	vars, err := getTemplateVars(ctx)
+	if err != nil {
+		return err
+	}
+
+	added, err := manager.ApplyTemplate(tmpl, vars)
+	if err != nil {
+		return err
+	}
+
+	printAffected(fmt.Sprintf("Applied template '%s', adding", tmpl.Name), added)
+	return nil
+}
+
+func HandleTemplateList(ctx *cli.Context) error {
+	dir, err := getTemplateDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	found := false
+	for _, entry := range entries {
+		if entry.IsDir() || !slices.Contains(tasks.TemplateExtensions, filepath.Ext(entry.Name())) {
+			continue
+		}
+
+		tmpl, err := tasks.LoadTemplate(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			fmt.Printf("%-20s (error: %v)\n", entry.Name(), err)
+			continue
+		}
+
+		fmt.Printf("%-20s %s\n", tmpl.Name, tmpl.Description)
+		found = true
+	}
+
+	if !found {
+		fmt.Printf("There are no templates in '%s'!\n", dir)
+	}
+
+	return nil
+}
+
+func printTemplateTasks(list []tasks.TemplateTask, depth int) {
+	for _, task := range list {
+		fmt.Printf("%s- %s", strings.Repeat("  ", depth+1), task.Description)
+		if len(task.Tags) > 0 {
+			fmt.Printf(" [%s]", strings.Join(task.Tags, ", "))
+		}
+
+		fmt.Println()
+		printTemplateTasks(task.Children, depth+1)
+	}
+}
+
+func HandleTemplateShow(ctx *cli.Context) error {
+	tmpl, err := findTemplate(ctx, ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Template '%s'", tmpl.Name)
+	if tmpl.Description != "" {
+		fmt.Printf(": %s", tmpl.Description)
+	}
+
+	fmt.Println()
+
+	if len(tmpl.Vars) > 0 {
+		names := make([]string, 0, len(tmpl.Vars))
+		for name := range tmpl.Vars {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+
+		fmt.Println("Variables:")
+		for _, name := range names {
+			if tmpl.Vars[name] == "" {
+				fmt.Printf("  %s (required)\n", name)
+			} else {
+				fmt.Printf("  %s (default: %s)\n", name, tmpl.Vars[name])
+			}
+		}
+	}
+
+	fmt.Println("Tasks:")
+	printTemplateTasks(tmpl.Tasks, 0)
+
+	return nil
+}
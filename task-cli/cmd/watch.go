@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pbnjk/backend/task-cli/tasks"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// What the database file looked like, to tell when it changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func getFileState(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// Returns the IDs of the tasks in a list that weren't in the previous one, or
+// that were updated since
+func getChangedTasks(before, after []tasks.Task) map[uint64]bool {
+	updatedAt := make(map[uint64]time.Time, len(before))
+	for _, task := range before {
+		updatedAt[task.Id] = task.UpdatedAt
+	}
+
+	changed := map[uint64]bool{}
+	for _, task := range after {
+		if at, ok := updatedAt[task.Id]; !ok || !at.Equal(task.UpdatedAt) {
+			changed[task.Id] = true
+		}
+	}
+
+	return changed
+}
+
+// Lists tasks again whenever the database changes, until interrupted
+//
+// The file is polled, which works everywhere. Nothing is ever changed, and
+// Ctrl+C ends the program without saving, so another process' changes are
+// never overwritten
+func watchList(ctx *cli.Context, opts tasks.QueryOptions) error {
+	interval := ctx.Duration("interval")
+	if interval <= 0 {
+		return errors.New("--interval must be more than 0")
+	}
+
+	verbose := getBoolSetting(ctx, "verbose", "list.verbose")
+	clear := term.IsTerminal(int(os.Stdout.Fd()))
+
+	var last []tasks.Task
+	state := getFileState(store.Path)
+
+	for first := true; ; first = false {
+		if !first {
+			time.Sleep(interval)
+
+			current := getFileState(store.Path)
+			if current == state {
+				continue
+			}
+
+			// The file may be caught halfway through a save, so failures are
+			// tried again on the next check
+			if err := manager.Reload(); err != nil {
+				continue
+			}
+
+			state = current
+		}
+
+		list, err := manager.Query(opts)
+		if err != nil {
+			return err
+		}
+
+		changed := map[uint64]bool{}
+		if !first {
+			changed = getChangedTasks(last, list)
+		}
+
+		if clear {
+			fmt.Print("\x1b[H\x1b[2J")
+		} else if !first {
+			fmt.Println()
+		}
+
+		fmt.Printf("Watching '%s' (Ctrl+C to stop), last updated %s\n\n", store.Path, formatTime(time.Now()))
+
+		if err := printTasks(list, verbose, ctx.String("group-by"), changed); err != nil {
+			return err
+		}
+
+		last = list
+	}
+}
@@ -2,10 +2,17 @@ module github.com/pbnjk/backend/task-cli
 
 go 1.23.1
 
-require github.com/urfave/cli/v2 v2.27.4
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/urfave/cli/v2 v2.27.4
+	golang.org/x/crypto v0.28.0
+	golang.org/x/term v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.4 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
+	golang.org/x/sys v0.26.0 // indirect
 )
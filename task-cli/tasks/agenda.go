@@ -0,0 +1,117 @@
+package tasks
+
+import (
+	"slices"
+	"time"
+)
+
+// Agenda buckets, in the order they're shown
+const (
+	AGENDA_OVERDUE   = "Overdue"
+	AGENDA_TODAY     = "Today"
+	AGENDA_TOMORROW  = "Tomorrow"
+	AGENDA_THIS_WEEK = "This week"
+	AGENDA_LATER     = "Later"
+)
+
+var AgendaBuckets []string = []string{
+	AGENDA_OVERDUE, AGENDA_TODAY, AGENDA_TOMORROW, AGENDA_THIS_WEEK, AGENDA_LATER,
+}
+
+// Returns the calendar day of a date, as midnight UTC
+//
+// Due dates are days rather than instants, so they keep the day they were set
+// for no matter what timezone they're looked at from
+func toDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Returns which agenda bucket a due date falls in, as seen from now (whose
+// timezone decides what "today" is)
+//
+// Weeks end on Sunday, like ISO weeks, so "This week" can be empty late in
+// the week
+func agendaBucket(due time.Time, now time.Time) string {
+	today := toDay(now)
+	day := toDay(due)
+
+	daysLeft := (int(time.Sunday-today.Weekday()) + 7) % 7
+
+	switch {
+	case day.Before(today):
+		return AGENDA_OVERDUE
+	case day.Equal(today):
+		return AGENDA_TODAY
+	case day.Equal(today.AddDate(0, 0, 1)):
+		return AGENDA_TOMORROW
+	case !day.After(today.AddDate(0, 0, daysLeft)):
+		return AGENDA_THIS_WEEK
+	default:
+		return AGENDA_LATER
+	}
+}
+
+// Returns whether a task isn't done and was due before today, as seen from
+// now
+func (t Task) IsOverdue(now time.Time) bool {
+	return t.Due != nil && t.Status != STATUS_DONE && toDay(*t.Due).Before(toDay(now))
+}
+
+// Sorts the pending tasks with a due date into agenda buckets, soonest first
+//
+// Empty buckets are left out
+func Agenda(list []Task, now time.Time) []Group {
+	byBucket := map[string][]Task{}
+	for _, task := range list {
+		if task.Due == nil || task.Status == STATUS_DONE {
+			continue
+		}
+
+		bucket := agendaBucket(*task.Due, now)
+		byBucket[bucket] = append(byBucket[bucket], task)
+	}
+
+	groups := []Group{}
+	for _, bucket := range AgendaBuckets {
+		if len(byBucket[bucket]) == 0 {
+			continue
+		}
+
+		list := byBucket[bucket]
+		slices.SortStableFunc(list, func(a, b Task) int {
+			return toDay(*a.Due).Compare(toDay(*b.Due))
+		})
+
+		groups = append(groups, Group{Name: bucket, Tasks: list})
+	}
+
+	return groups
+}
+
+// Returns the agenda of the active tasks, with "today" decided by the given
+// timezone
+func (m *Manager) Agenda(loc *time.Location) []Group {
+	list := make([]Task, 0, len(m.data.Tasks))
+	for _, id := range getSortedIDs(m.data.Tasks) {
+		list = append(list, m.data.Tasks[id])
+	}
+
+	return Agenda(list, m.clock.Now().In(loc))
+}
+
+// Counts the pending active tasks due on each day of a month
+func (m *Manager) DueCounts(year int, month time.Month) map[int]int {
+	counts := map[int]int{}
+	for _, task := range m.data.Tasks {
+		if task.Due == nil || task.Status == STATUS_DONE {
+			continue
+		}
+
+		if y, mo, d := task.Due.Date(); y == year && mo == month {
+			counts[d]++
+		}
+	}
+
+	return counts
+}
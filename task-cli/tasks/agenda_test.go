@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAgenda(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2026, time.October, 14, 9, 30, 0, 0, time.UTC)
+
+	due := func(id uint64, date string, status TaskStatus) Task {
+		d, err := time.ParseInLocation(DUE_FORMAT, date, time.FixedZone("BRT", -3*60*60))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return Task{Id: id, Status: status, Due: &d}
+	}
+
+	list := []Task{
+		due(1, "2026-10-20", STATUS_TODO),
+		due(2, "2026-10-13", STATUS_TODO),
+		due(3, "2026-10-14", STATUS_IN_PROGRESS),
+		due(4, "2026-10-15", STATUS_TODO),
+		due(5, "2026-10-18", STATUS_TODO),
+		due(6, "2026-10-16", STATUS_TODO),
+		due(7, "2026-10-01", STATUS_DONE),
+		{Id: 8, Status: STATUS_TODO},
+	}
+
+	want := map[string][]uint64{
+		AGENDA_OVERDUE:   {2},
+		AGENDA_TODAY:     {3},
+		AGENDA_TOMORROW:  {4},
+		AGENDA_THIS_WEEK: {6, 5},
+		AGENDA_LATER:     {1},
+	}
+
+	groups := Agenda(list, now)
+	if len(groups) != len(AgendaBuckets) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(AgendaBuckets))
+	}
+
+	for idx, group := range groups {
+		if group.Name != AgendaBuckets[idx] {
+			t.Errorf("group %d is %q, want %q", idx, group.Name, AgendaBuckets[idx])
+		}
+
+		if got := taskIDs(group.Tasks); !slices.Equal(got, want[group.Name]) {
+			t.Errorf("%s: got %v, want %v", group.Name, got, want[group.Name])
+		}
+	}
+
+	// Late enough in the day that it's already tomorrow further east
+	late := time.Date(2026, time.October, 14, 23, 0, 0, 0, time.UTC).In(time.FixedZone("JST", 9*60*60))
+	if groups := Agenda(list[2:3], late); groups[0].Name != AGENDA_OVERDUE {
+		t.Errorf("got %q, want %q", groups[0].Name, AGENDA_OVERDUE)
+	}
+}
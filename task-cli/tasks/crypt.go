@@ -0,0 +1,157 @@
+package tasks
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KDF_SCRYPT    = "scrypt"
+	CIPHER_AESGCM = "aes-256-gcm"
+
+	SCRYPT_N = 1 << 15
+	SCRYPT_R = 8
+	SCRYPT_P = 1
+
+	// Bounds on the scrypt parameters read from a database, so that a
+	// tampered one can't make deriving its key take all the memory (which is
+	// about 128 * N * R bytes) or time there is
+	MIN_SCRYPT_N      = 1 << 10
+	MAX_SCRYPT_N      = 1 << 20
+	MAX_SCRYPT_R      = 32
+	MAX_SCRYPT_P      = 16
+	MAX_SCRYPT_MEMORY = 1 << 30
+
+	SALT_SIZE = 16
+	KEY_SIZE  = 32
+)
+
+// How an encrypted database was encrypted, stored alongside the ciphertext
+type EncryptionParams struct {
+	KDF    string `json:"kdf"`
+	Salt   []byte `json:"salt"`
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	Cipher string `json:"cipher"`
+	Nonce  []byte `json:"nonce"`
+}
+
+// The on-disk format of an encrypted database
+//
+// The data is the whole plaintext database, sealed with AES-GCM
+type EncryptedDB struct {
+	Encryption *EncryptionParams `json:"encryption"`
+	Data       []byte            `json:"data"`
+}
+
+// A key derived from a passphrase, along with how it was derived
+type dbKey struct {
+	params EncryptionParams
+	key    []byte
+}
+
+func deriveKey(passphrase []byte, params EncryptionParams) (*dbKey, error) {
+	if params.KDF != KDF_SCRYPT || params.Cipher != CIPHER_AESGCM {
+		return nil, fmt.Errorf("Unsupported encryption (%s, %s)", params.KDF, params.Cipher)
+	}
+
+	if params.N < MIN_SCRYPT_N || params.N > MAX_SCRYPT_N || params.R < 1 || params.R > MAX_SCRYPT_R ||
+		params.P < 1 || params.P > MAX_SCRYPT_P || 128*params.N*params.R > MAX_SCRYPT_MEMORY {
+		return nil, fmt.Errorf("Unsupported scrypt parameters (N=%d, r=%d, p=%d)", params.N, params.R, params.P)
+	}
+
+	if len(params.Salt) == 0 {
+		return nil, errors.New("The database has no salt for its key!")
+	}
+
+	key, err := scrypt.Key(passphrase, params.Salt, params.N, params.R, params.P, KEY_SIZE)
+	if err != nil {
+		return nil, fmt.Errorf("Error deriving key: %v", err)
+	}
+
+	return &dbKey{params: params, key: key}, nil
+}
+
+// Creates a key with a fresh salt from a passphrase
+func newKey(passphrase []byte) (*dbKey, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("The passphrase can't be empty!")
+	}
+
+	salt := make([]byte, SALT_SIZE)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return deriveKey(passphrase, EncryptionParams{
+		KDF:    KDF_SCRYPT,
+		Salt:   salt,
+		N:      SCRYPT_N,
+		R:      SCRYPT_R,
+		P:      SCRYPT_P,
+		Cipher: CIPHER_AESGCM,
+	})
+}
+
+func (k *dbKey) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (k *dbKey) encrypt(plaintext []byte) ([]byte, error) {
+	gcm, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	// A fresh nonce on every save, since the key stays the same
+	params := k.params
+	params.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(params.Nonce); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(EncryptedDB{
+		Encryption: &params,
+		Data:       gcm.Seal(nil, params.Nonce, plaintext, nil),
+	})
+}
+
+// Returns whether the key was derived with the given parameters (the nonce
+// aside, which changes with every save)
+func (k *dbKey) matches(params EncryptionParams) bool {
+	return k.params.KDF == params.KDF && bytes.Equal(k.params.Salt, params.Salt) &&
+		k.params.N == params.N && k.params.R == params.R && k.params.P == params.P &&
+		k.params.Cipher == params.Cipher
+}
+
+func (k *dbKey) decrypt(db EncryptedDB) ([]byte, error) {
+	gcm, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	// Open panics on nonces of the wrong size
+	if len(db.Encryption.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("Couldn't decrypt the database (its nonce is damaged)")
+	}
+
+	plaintext, err := gcm.Open(nil, db.Encryption.Nonce, db.Data, nil)
+	if err != nil {
+		return nil, errors.New("Couldn't decrypt the database (wrong passphrase?)")
+	}
+
+	return plaintext, nil
+}
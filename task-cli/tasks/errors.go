@@ -0,0 +1,89 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound          = errors.New("No such task")
+	ErrInvalidTransition = errors.New("Invalid status transition")
+	ErrNoMatch           = errors.New("No tasks match the given selection!")
+	ErrWipLimitExceeded  = errors.New("WIP limit exceeded")
+	ErrEmptyDescription  = errors.New("Must provide a task description")
+	ErrParentCycle       = errors.New("A task can't be its own parent (or ancestor)!")
+	ErrHookVeto          = errors.New("A hook refused the change")
+)
+
+// Returned when a task doesn't exist. Matches ErrNotFound
+type NotFoundError struct {
+	Id       uint64
+	Archived bool
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Archived {
+		return fmt.Sprintf("No archived task with ID %v!", e.Id)
+	}
+
+	return fmt.Sprintf("No task with ID %v!", e.Id)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// Returned when a task can't move to a status. Matches ErrInvalidTransition
+type TransitionError struct {
+	Id   uint64
+	From TaskStatus
+	To   TaskStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("Task %v is already '%s'!", e.Id, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Returned when a change would put more tasks in a status than its WIP limit
+// allows. Matches ErrWipLimitExceeded
+type WipLimitError struct {
+	Status TaskStatus
+	Count  int
+	Limit  WipLimit
+}
+
+func (e *WipLimitError) Error() string {
+	return fmt.Sprintf(
+		"This would put %d tasks in '%s', over the limit of %d!",
+		e.Count, e.Status, e.Limit.Max,
+	)
+}
+
+func (e *WipLimitError) Is(target error) bool {
+	return target == ErrWipLimitExceeded
+}
+
+// Returned when a hook refuses a change. Matches ErrHookVeto
+type HookError struct {
+	Event  string
+	Hook   string
+	Id     uint64
+	Output string
+}
+
+func (e *HookError) Error() string {
+	msg := fmt.Sprintf("The %s hook '%s' refused the change to task %v!", e.Event, e.Hook, e.Id)
+	if e.Output != "" {
+		msg += "\n" + e.Output
+	}
+
+	return msg
+}
+
+func (e *HookError) Is(target error) bool {
+	return target == ErrHookVeto
+}
@@ -0,0 +1,213 @@
+package tasks
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EstimateUnit int
+
+const (
+	UNIT_HOURS EstimateUnit = iota
+	UNIT_POINTS
+)
+
+func (u EstimateUnit) String() string {
+	if u == UNIT_POINTS {
+		return "pt"
+	}
+
+	return "h"
+}
+
+// How much work a task is expected to take, in hours or story points
+type Estimate struct {
+	Amount float64
+	Unit   EstimateUnit
+}
+
+// Parses an estimate such as "3h", "90m" (kept as hours), "5pt" or "5p"
+func ParseEstimate(estimate string) (Estimate, error) {
+	e := strings.ToLower(strings.TrimSpace(estimate))
+
+	unit, scale := UNIT_HOURS, 1.0
+	switch {
+	case strings.HasSuffix(e, "pts"):
+		unit, e = UNIT_POINTS, strings.TrimSuffix(e, "pts")
+	case strings.HasSuffix(e, "pt"):
+		unit, e = UNIT_POINTS, strings.TrimSuffix(e, "pt")
+	case strings.HasSuffix(e, "p"):
+		unit, e = UNIT_POINTS, strings.TrimSuffix(e, "p")
+	case strings.HasSuffix(e, "h"):
+		e = strings.TrimSuffix(e, "h")
+	case strings.HasSuffix(e, "m"):
+		e, scale = strings.TrimSuffix(e, "m"), 1.0/60
+	default:
+		return Estimate{}, fmt.Errorf("'%s' is not a valid estimate (must be like 3h, 90m or 5pt)", estimate)
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(e), 64)
+	if err != nil || amount < 0 {
+		return Estimate{}, fmt.Errorf("'%s' is not a valid estimate (must be like 3h, 90m or 5pt)", estimate)
+	}
+
+	return Estimate{Amount: amount * scale, Unit: unit}, nil
+}
+
+func (e Estimate) String() string {
+	return strconv.FormatFloat(e.Amount, 'f', -1, 64) + e.Unit.String()
+}
+
+func (e Estimate) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *Estimate) UnmarshalText(text []byte) error {
+	parsed, err := ParseEstimate(string(text))
+	if err != nil {
+		return err
+	}
+
+	*e = parsed
+	return nil
+}
+
+// Compares estimates, with hours before points and tasks without an estimate
+// coming last
+func compareEstimate(a, b Task) int {
+	switch {
+	case a.Estimate == nil && b.Estimate == nil:
+		return 0
+	case a.Estimate == nil:
+		return 1
+	case b.Estimate == nil:
+		return -1
+	default:
+		return cmp.Or(cmp.Compare(a.Estimate.Unit, b.Estimate.Unit), cmp.Compare(a.Estimate.Amount, b.Estimate.Amount))
+	}
+}
+
+// Returns how long a task has spent in progress, going by its history
+func (t Task) TrackedTime(now time.Time) time.Duration {
+	inProgress := STATUS_IN_PROGRESS.String()
+
+	tracked := time.Duration(0)
+	var since *time.Time
+
+	for _, change := range t.History {
+		if change.Field != FIELD_STATUS {
+			continue
+		}
+
+		if change.To == inProgress && since == nil {
+			at := change.At
+			since = &at
+		} else if change.From == inProgress && since != nil {
+			tracked += change.At.Sub(*since)
+			since = nil
+		}
+	}
+
+	if since != nil && t.Status == STATUS_IN_PROGRESS {
+		tracked += now.Sub(*since)
+	}
+
+	return tracked
+}
+
+// Returns whether a task has children that aren't done yet
+func (m *Manager) IsBlocked(id uint64) bool {
+	return slices.ContainsFunc(m.Children(id), func(child Task) bool {
+		return child.Status != STATUS_DONE
+	})
+}
+
+// The tasks that fit in some capacity
+type Plan struct {
+	Capacity Estimate
+	Tasks    []Task
+	Total    float64
+
+	// To-do tasks that weren't considered, for not being estimated in the
+	// capacity's unit
+	Unestimated []Task
+}
+
+// Picks the to-do tasks to work on next, that fit in the given capacity
+//
+// Blocked tasks (with unfinished children) are left out. The rest are taken
+// by priority, then by due date and then by ID, skipping the ones that don't
+// fit in what's left
+func (m *Manager) Plan(capacity Estimate) Plan {
+	plan := Plan{Capacity: capacity}
+
+	candidates := []Task{}
+	for _, id := range getSortedIDs(m.data.Tasks) {
+		task := m.data.Tasks[id]
+		if task.Status != STATUS_TODO || m.IsBlocked(id) {
+			continue
+		}
+
+		if task.Estimate == nil || task.Estimate.Unit != capacity.Unit {
+			plan.Unestimated = append(plan.Unestimated, task)
+			continue
+		}
+
+		candidates = append(candidates, task)
+	}
+
+	slices.SortStableFunc(candidates, func(a, b Task) int {
+		return cmp.Or(cmp.Compare(b.Priority, a.Priority), compareDue(a, b))
+	})
+
+	for _, task := range candidates {
+		if plan.Total+task.Estimate.Amount <= capacity.Amount {
+			plan.Tasks = append(plan.Tasks, task)
+			plan.Total += task.Estimate.Amount
+		}
+	}
+
+	return plan
+}
+
+// Estimates and tracked time for the tasks in a status
+type EstimateTotals struct {
+	Status  TaskStatus
+	Count   int
+	Hours   float64
+	Points  float64
+	Tracked time.Duration
+}
+
+// Sums up the estimates and tracked time of the active tasks, per status
+func (m *Manager) EstimateTotals() []EstimateTotals {
+	now := m.clock.Now()
+
+	totals := make([]EstimateTotals, len(Statuses))
+	for idx, status := range Statuses {
+		totals[idx].Status = status
+	}
+
+	for _, task := range m.data.Tasks {
+		t := &totals[slices.Index(Statuses, task.Status)]
+
+		t.Count++
+		t.Tracked += task.TrackedTime(now)
+
+		if task.Estimate == nil {
+			continue
+		}
+
+		if task.Estimate.Unit == UNIT_POINTS {
+			t.Points += task.Estimate.Amount
+		} else {
+			t.Hours += task.Estimate.Amount
+		}
+	}
+
+	return totals
+}
@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseEstimate(t *testing.T) {
+	cases := map[string]Estimate{
+		"3h":    {3, UNIT_HOURS},
+		"1.5h":  {1.5, UNIT_HOURS},
+		"90m":   {1.5, UNIT_HOURS},
+		"5pt":   {5, UNIT_POINTS},
+		"8pts":  {8, UNIT_POINTS},
+		" 2P ":  {2, UNIT_POINTS},
+		"0h":    {0, UNIT_HOURS},
+		"13 pt": {13, UNIT_POINTS},
+	}
+
+	for input, want := range cases {
+		got, err := ParseEstimate(input)
+		if err != nil || got != want {
+			t.Errorf("ParseEstimate(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "3", "h", "-2h", "3x", "twoh"} {
+		if _, err := ParseEstimate(input); err == nil {
+			t.Errorf("ParseEstimate(%q) should have failed", input)
+		}
+	}
+}
+
+func TestTrackedTime(t *testing.T) {
+	m, clock := newTestManager(t)
+	mustAdd(t, m, "tracked")
+
+	mark := func(status TaskStatus, after time.Duration) {
+		t.Helper()
+
+		clock.advance(after)
+		if _, err := m.Mark([]uint64{1}, status, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mark(STATUS_IN_PROGRESS, time.Hour)
+	mark(STATUS_TODO, 2*time.Hour)
+	mark(STATUS_IN_PROGRESS, 5*time.Hour)
+	clock.advance(30 * time.Minute)
+
+	task, _ := m.Get(1)
+	if got := task.TrackedTime(clock.now); got != 150*time.Minute {
+		t.Errorf("got %v tracked, want 2h30m", got)
+	}
+
+	mark(STATUS_DONE, 0)
+	clock.advance(10 * time.Hour)
+
+	task, _ = m.Get(1)
+	if got := task.TrackedTime(clock.now); got != 150*time.Minute {
+		t.Errorf("got %v tracked once done, want 2h30m", got)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	add := func(desc, estimate string, priority TaskPriority, parent uint64) {
+		t.Helper()
+
+		changes := TaskChanges{Priority: &priority}
+		if estimate != "" {
+			e, err := ParseEstimate(estimate)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes.Estimate = &e
+		}
+
+		if parent != 0 {
+			changes.Parent = &parent
+		}
+
+		if _, err := m.Add(desc, nil, changes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add("blocked", "2h", PRIORITY_HIGH, 0)       // 1
+	add("child", "3h", PRIORITY_LOW, 1)          // 2
+	add("too big", "11h", PRIORITY_HIGH, 0)      // 3
+	add("important", "4h", PRIORITY_HIGH, 0)     // 4
+	add("medium", "2h", PRIORITY_MEDIUM, 0)      // 5
+	add("points", "3pt", PRIORITY_HIGH, 0)       // 6
+	add("unestimated", "", PRIORITY_HIGH, 0)     // 7
+	add("started", "1h", PRIORITY_HIGH, 0)       // 8
+	add("fills the gap", "1h", PRIORITY_NONE, 0) // 9
+
+	if _, err := m.Mark([]uint64{8}, STATUS_IN_PROGRESS, false); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := m.Plan(Estimate{Amount: 10, Unit: UNIT_HOURS})
+
+	if got, want := taskIDs(plan.Tasks), []uint64{4, 5, 2, 9}; !slices.Equal(got, want) {
+		t.Errorf("planned %v, want %v", got, want)
+	}
+
+	if plan.Total != 10 {
+		t.Errorf("got a total of %v, want 10", plan.Total)
+	}
+
+	if got, want := taskIDs(plan.Unestimated), []uint64{6, 7}; !slices.Equal(got, want) {
+		t.Errorf("got unestimated %v, want %v", got, want)
+	}
+
+	totals := m.EstimateTotals()
+	if totals[0].Count != 8 || totals[0].Hours != 23 || totals[0].Points != 3 || totals[1].Hours != 1 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+}
@@ -0,0 +1,218 @@
+package tasks
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// A single .gitignore pattern
+type ignoreRule struct {
+	// Directory the .gitignore is in, relative to the top of the tree, as a
+	// slash path ("" for the top itself)
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// The .gitignore rules in effect while walking a tree
+//
+// It supports the common subset of the format: comments, negation, trailing
+// slashes for directories, leading slashes for anchoring and "**"
+type gitignore struct {
+	// The top of the git repository the tree is in, or the tree itself when
+	// it isn't in one. Paths are relative to it
+	top   string
+	rules []ignoreRule
+}
+
+// Returns the rules for walking a tree from root (an absolute path) down
+//
+// When the tree is inside a git repository, the rules from .git/info/exclude
+// and from the .gitignore files above root apply to it too
+func newGitignore(root string) (*gitignore, error) {
+	g := &gitignore{top: root}
+
+	// Worktrees and submodules have a .git file instead, and no exclude file
+	// of their own
+	hasGitDir := false
+
+	for dir := root; ; {
+		if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			g.top = dir
+			hasGitDir = info.IsDir()
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return g, nil
+		}
+
+		dir = parent
+	}
+
+	if hasGitDir {
+		if err := g.loadFile(filepath.Join(g.top, ".git", "info", "exclude"), ""); err != nil {
+			return nil, err
+		}
+	}
+
+	rel, err := g.rel(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// The root's own .gitignore is read while walking
+	if rel == "" {
+		return g, nil
+	}
+
+	dir := ""
+	if err := g.load(dir); err != nil {
+		return nil, err
+	}
+
+	for _, name := range strings.Split(rel, "/")[:strings.Count(rel, "/")] {
+		dir = path.Join(dir, name)
+		if err := g.load(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// Returns a path relative to the top, as a slash path ("" for the top itself)
+func (g *gitignore) rel(file string) (string, error) {
+	rel, err := filepath.Rel(g.top, file)
+	if err != nil {
+		return "", err
+	}
+
+	if rel == "." {
+		return "", nil
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// Reads the .gitignore in dir (relative to the top), if there's one
+func (g *gitignore) load(dir string) error {
+	return g.loadFile(filepath.Join(g.top, filepath.FromSlash(dir), ".gitignore"), dir)
+}
+
+// Reads an ignore file whose patterns are relative to dir, if it exists
+func (g *gitignore) loadFile(name, dir string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: dir}
+
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		// A slash anywhere but the end anchors the pattern to its directory
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		rule.pattern = line
+		g.rules = append(g.rules, rule)
+	}
+
+	return scanner.Err()
+}
+
+// Matches a slash path against a pattern, segment by segment. A "**" segment
+// matches any number of directories, including none
+func matchPattern(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := range len(name) + 1 {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchSegments(pattern[1:], name[1:])
+}
+
+// Returns whether a path (relative to the top, as a slash path) is
+// ignored. Later rules win, so negations can re-include things
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		name := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+
+			name = strings.TrimPrefix(rel, rule.base+"/")
+		}
+
+		matched := false
+		if rule.anchored {
+			matched = matchPattern(rule.pattern, name)
+		} else {
+			matched = matchPattern(rule.pattern, path.Base(name))
+		}
+
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
@@ -0,0 +1,137 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Events hooks can run on
+const (
+	HOOK_ON_ADD    = "on-add"
+	HOOK_ON_MODIFY = "on-modify"
+	HOOK_ON_DONE   = "on-done"
+	HOOK_ON_DELETE = "on-delete"
+)
+
+var HookEvents []string = []string{HOOK_ON_ADD, HOOK_ON_MODIFY, HOOK_ON_DONE, HOOK_ON_DELETE}
+
+// Runs hooks before a task changes. The old task is nil for new tasks, and
+// the new one is nil for deleted tasks
+//
+// Returning an error (usually a *HookError) stops the change
+type Hooks interface {
+	Run(event string, old, new *Task) error
+}
+
+// Runs the executables in a directory as hooks, like git does
+//
+// A hook is named after its event, optionally followed by a dot or a dash
+// and anything else ("on-done", "on-done.sh", "on-done-notify"), so there can
+// be many per event. They run in alphabetical order, each getting the old and
+// the new task on stdin, as a line of JSON each ("null" if there's no task).
+// The first hook to exit with a non-zero status vetoes the change, and what it
+// printed is shown to the user
+type HookDir struct {
+	Path string
+
+	// Where to copy what successful hooks print. Nil discards it
+	Output io.Writer
+}
+
+// Returns the hooks for an event, sorted by name
+func (h HookDir) Find(event string) ([]string, error) {
+	entries, err := os.ReadDir(h.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	hooks := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if name != event && !strings.HasPrefix(name, event+".") && !strings.HasPrefix(name, event+"-") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		hooks = append(hooks, filepath.Join(h.Path, name))
+	}
+
+	return hooks, nil
+}
+
+func (h HookDir) Run(event string, old, new *Task) error {
+	hooks, err := h.Find(event)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	input := &bytes.Buffer{}
+	encoder := json.NewEncoder(input)
+
+	if err := encoder.Encode(old); err != nil {
+		return err
+	}
+
+	if err := encoder.Encode(new); err != nil {
+		return err
+	}
+
+	id := uint64(0)
+	if new != nil {
+		id = new.Id
+	} else if old != nil {
+		id = old.Id
+	}
+
+	for _, hook := range hooks {
+		cmd := exec.Command(hook)
+		cmd.Stdin = bytes.NewReader(input.Bytes())
+		cmd.Env = append(os.Environ(), "TASK_CLI_EVENT="+event)
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				return err
+			}
+
+			return &HookError{
+				Event:  event,
+				Hook:   filepath.Base(hook),
+				Id:     id,
+				Output: strings.TrimSpace(string(output)),
+			}
+		}
+
+		if h.Output != nil {
+			h.Output.Write(output)
+		}
+	}
+
+	return nil
+}
+
+// Sets the hooks to run before tasks change. Nil disables them
+func (m *Manager) SetHooks(hooks Hooks) {
+	m.hooks = hooks
+}
+
+func (m *Manager) runHook(event string, old, new *Task) error {
+	if m.hooks == nil {
+		return nil
+	}
+
+	return m.hooks.Run(event, old, new)
+}
@@ -0,0 +1,141 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Records the hooks it's asked to run, refusing changes to one task
+type fakeHooks struct {
+	calls []string
+	veto  uint64
+}
+
+func (h *fakeHooks) Run(event string, old, new *Task) error {
+	id := uint64(0)
+	if new != nil {
+		id = new.Id
+	} else {
+		id = old.Id
+	}
+
+	h.calls = append(h.calls, event)
+	if id == h.veto {
+		return &HookError{Event: event, Hook: "fake", Id: id}
+	}
+
+	return nil
+}
+
+func TestHooksRunOnChanges(t *testing.T) {
+	m, _ := newTestManager(t)
+	hooks := &fakeHooks{}
+	m.SetHooks(hooks)
+
+	mustAdd(t, m, "first")
+	mustAdd(t, m, "second")
+
+	desc := "changed"
+	if _, err := m.Update([]uint64{1}, TaskChanges{Description: &desc}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Mark([]uint64{1}, STATUS_IN_PROGRESS, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Mark([]uint64{1}, STATUS_DONE, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Delete([]uint64{2}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{HOOK_ON_ADD, HOOK_ON_ADD, HOOK_ON_MODIFY, HOOK_ON_MODIFY, HOOK_ON_DONE, HOOK_ON_DELETE}
+	if strings.Join(hooks.calls, " ") != strings.Join(want, " ") {
+		t.Errorf("got hooks %v, want %v", hooks.calls, want)
+	}
+}
+
+func TestHookVetoIsAtomic(t *testing.T) {
+	m, _ := newTestManager(t)
+	mustAdd(t, m, "first")
+	mustAdd(t, m, "second")
+
+	m.SetHooks(&fakeHooks{veto: 2})
+
+	if _, err := m.Mark([]uint64{1, 2}, STATUS_DONE, false); !errors.Is(err, ErrHookVeto) {
+		t.Fatalf("got %v, want ErrHookVeto", err)
+	}
+
+	if task, _ := m.Get(1); task.Status != STATUS_TODO || len(task.History) != 1 {
+		t.Errorf("task 1 changed despite the veto: %+v", task)
+	}
+
+	if _, err := m.Delete([]uint64{1, 2}); !errors.Is(err, ErrHookVeto) {
+		t.Fatalf("got %v, want ErrHookVeto", err)
+	}
+
+	if ids := m.IDs(); len(ids) != 2 {
+		t.Errorf("got tasks %v after a vetoed delete", ids)
+	}
+
+	if _, err := m.Add("third", nil, TaskChanges{}); err != nil {
+		t.Errorf("Add: %v", err)
+	}
+}
+
+func TestHookDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+
+	scripts := map[string]string{
+		"on-add.sh":     "#!/bin/sh\ncat > " + out + "\n",
+		"on-delete":     "#!/bin/sh\necho nope\nexit 1\n",
+		"on-add.txt":    "not executable",
+		"on-addendum":   "#!/bin/sh\nexit 1\n",
+		"on-modify.bak": "#!/bin/sh\nexit 1\n",
+	}
+
+	for name, script := range scripts {
+		mode := os.FileMode(0755)
+		if name == "on-add.txt" {
+			mode = 0644
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hooks := HookDir{Path: dir}
+	task := Task{Id: 7, Description: "hooked"}
+
+	if err := hooks.Run(HOOK_ON_ADD, nil, &task); err != nil {
+		t.Fatalf("on-add: %v", err)
+	}
+
+	input, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(input)), "\n")
+	if len(lines) != 2 || lines[0] != "null" || !strings.Contains(lines[1], `"desc":"hooked"`) {
+		t.Errorf("unexpected hook input: %q", input)
+	}
+
+	var hookErr *HookError
+	if err := hooks.Run(HOOK_ON_DELETE, &task, nil); !errors.As(err, &hookErr) || hookErr.Output != "nope" || hookErr.Id != 7 {
+		t.Errorf("got %v, want a veto from on-delete", err)
+	}
+}
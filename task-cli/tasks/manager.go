@@ -0,0 +1,540 @@
+package tasks
+
+import (
+	"slices"
+	"time"
+)
+
+// Tells the time. Swappable so that tests can control it
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Owns a set of tasks, and every change made to them
+//
+// Changes only touch memory until Save is called. Methods that change many
+// tasks check all of them first (hooks included), so they either change every
+// task or none
+type Manager struct {
+	store Store
+	clock Clock
+	data  *Data
+	actor string
+	hooks Hooks
+}
+
+// Creates a manager, loading the tasks in the store
+func NewManager(store Store, clock Clock) (*Manager, error) {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+
+	data, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		store: store,
+		clock: clock,
+		data:  data,
+	}, nil
+}
+
+func (m *Manager) Save() error {
+	return m.store.Save(m.data)
+}
+
+// Throws away unsaved changes, loading the tasks in the store again
+func (m *Manager) Reload() error {
+	data, err := m.store.Load()
+	if err != nil {
+		return err
+	}
+
+	m.data = data
+	return nil
+}
+
+func (m *Manager) Store() Store {
+	return m.store
+}
+
+func (m *Manager) Now() time.Time {
+	return m.clock.Now()
+}
+
+// Sets who's making changes, as recorded in the tasks' history
+func (m *Manager) SetActor(actor string) {
+	m.actor = actor
+}
+
+// Records a change in a task's history
+func (m *Manager) record(task *Task, at time.Time, field, from, to string) {
+	task.History = append(task.History, Change{
+		At:    at,
+		Actor: m.actor,
+		Field: field,
+		From:  from,
+		To:    to,
+	})
+}
+
+func getSortedIDs(tasks map[uint64]Task) []uint64 {
+	ids := make([]uint64, 0, len(tasks))
+	for k := range tasks {
+		ids = append(ids, k)
+	}
+
+	slices.Sort(ids)
+	return ids
+}
+
+// Returns the IDs of all active tasks, sorted
+func (m *Manager) IDs() []uint64 {
+	return getSortedIDs(m.data.Tasks)
+}
+
+// Returns an active task
+func (m *Manager) Get(id uint64) (Task, error) {
+	task, ok := m.data.Tasks[id]
+	if !ok {
+		return Task{}, &NotFoundError{Id: id}
+	}
+
+	return task, nil
+}
+
+// Returns an archived task
+func (m *Manager) GetArchived(id uint64) (Task, error) {
+	task, ok := m.data.Archive[id]
+	if !ok {
+		return Task{}, &NotFoundError{Id: id, Archived: true}
+	}
+
+	return task, nil
+}
+
+// Returns whether an ID is taken, either by an active or an archived task
+func (m *Manager) isIDUsed(id uint64) bool {
+	if _, ok := m.data.Tasks[id]; ok {
+		return true
+	}
+
+	_, ok := m.data.Archive[id]
+	return ok
+}
+
+// Returns the lowest free ID next to a used one, so IDs stay sequential
+func (m *Manager) nextID() uint64 {
+	ids := getSortedIDs(m.data.Tasks)
+	for id := range m.data.Archive {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	id := uint64(1)
+	lastIdx := len(ids) - 1
+
+	for cidx, cid := range ids {
+		if cid > 1 {
+			if !m.isIDUsed(cid - 1) {
+				id = cid - 1
+				break
+			}
+		}
+
+		if cidx == lastIdx || cid+1 != ids[cidx+1] {
+			id = cid + 1
+			break
+		}
+	}
+
+	return id
+}
+
+// Checks that a task can be made a child of parent
+//
+// The parent has to exist, and can't be the task itself or one of its
+// descendants. An ID of 0 is for new tasks, which have no descendants yet
+func (m *Manager) checkParent(id uint64, parent uint64) error {
+	if parent == 0 {
+		return nil
+	}
+
+	for ancestor := parent; ancestor != 0; ancestor = m.data.Tasks[ancestor].Parent {
+		if _, ok := m.data.Tasks[ancestor]; !ok {
+			if ancestor == parent {
+				return &NotFoundError{Id: parent}
+			}
+
+			// The rest of the chain was archived or deleted
+			break
+		}
+
+		if ancestor == id {
+			return ErrParentCycle
+		}
+	}
+
+	return nil
+}
+
+// Returns the active tasks whose parent is the given task
+func (m *Manager) Children(id uint64) []Task {
+	children := []Task{}
+	for _, cid := range getSortedIDs(m.data.Tasks) {
+		if task := m.data.Tasks[cid]; task.Parent == id {
+			children = append(children, task)
+		}
+	}
+
+	return children
+}
+
+// Makes a change that goes through other methods one step at a time (like
+// adding many tasks), putting everything back the way it was if any step
+// fails
+func (m *Manager) allOrNothing(change func() error) error {
+	saved := m.data.clone()
+	if err := change(); err != nil {
+		m.data = saved
+		return err
+	}
+
+	return nil
+}
+
+// Adds a new to-do task
+func (m *Manager) Add(desc string, tags []string, changes TaskChanges) (Task, error) {
+	if desc == "" {
+		return Task{}, ErrEmptyDescription
+	}
+
+	if changes.Parent != nil {
+		if err := m.checkParent(0, *changes.Parent); err != nil {
+			return Task{}, err
+		}
+	}
+
+	now := m.clock.Now()
+	task := Task{
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Description: desc,
+		Id:          m.nextID(),
+		Status:      STATUS_TODO,
+		Tags:        tags,
+	}
+
+	changes.apply(&task)
+	m.record(&task, now, FIELD_CREATED, "", task.Description)
+
+	if err := m.runHook(HOOK_ON_ADD, nil, &task); err != nil {
+		return Task{}, err
+	}
+
+	m.data.Tasks[task.Id] = task
+	return task, nil
+}
+
+// Checks that every ID belongs to an active task
+func (m *Manager) checkIDs(ids []uint64) error {
+	for _, id := range ids {
+		if _, ok := m.data.Tasks[id]; !ok {
+			return &NotFoundError{Id: id}
+		}
+	}
+
+	return nil
+}
+
+// Applies the same changes to many tasks
+func (m *Manager) Update(ids []uint64, changes TaskChanges) ([]Task, error) {
+	if err := m.checkIDs(ids); err != nil {
+		return nil, err
+	}
+
+	if changes.Description != nil && *changes.Description == "" {
+		return nil, ErrEmptyDescription
+	}
+
+	if changes.Parent != nil {
+		for _, id := range ids {
+			if err := m.checkParent(id, *changes.Parent); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := m.clock.Now()
+	updated := make([]Task, 0, len(ids))
+
+	for _, id := range ids {
+		old := m.data.Tasks[id]
+		task := old
+
+		changes.apply(&task)
+		task.UpdatedAt = now
+
+		if task.Description != old.Description {
+			m.record(&task, now, FIELD_DESCRIPTION, old.Description, task.Description)
+		}
+
+		if err := m.runHook(HOOK_ON_MODIFY, &old, &task); err != nil {
+			return nil, err
+		}
+
+		updated = append(updated, task)
+	}
+
+	for _, task := range updated {
+		m.data.Tasks[task.Id] = task
+	}
+
+	return updated, nil
+}
+
+// Checks whether moving the given tasks into a status would go over its WIP
+// limit, returning a *WipLimitError if so
+//
+// The error is returned for warn-only limits too, so callers can warn about it
+func (m *Manager) CheckWipLimit(status TaskStatus, ids []uint64) error {
+	limit, ok := m.data.WipLimits[status]
+	if !ok {
+		return nil
+	}
+
+	total := m.CountWithStatus(status)
+	for _, id := range ids {
+		if task, ok := m.data.Tasks[id]; ok && task.Status != status {
+			total++
+		}
+	}
+
+	if total <= limit.Max {
+		return nil
+	}
+
+	return &WipLimitError{Status: status, Count: total, Limit: limit}
+}
+
+// Moves many tasks into a status
+//
+// Fails if any task is already in that status, or if the status' WIP limit
+// is strict and would be exceeded (unless forced)
+func (m *Manager) Mark(ids []uint64, status TaskStatus, force bool) ([]Task, error) {
+	if err := m.checkIDs(ids); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if task := m.data.Tasks[id]; task.Status == status {
+			return nil, &TransitionError{Id: id, From: task.Status, To: status}
+		}
+	}
+
+	if !force {
+		if err := m.CheckWipLimit(status, ids); err != nil {
+			if !err.(*WipLimitError).Limit.WarnOnly {
+				return nil, err
+			}
+		}
+	}
+
+	event := HOOK_ON_MODIFY
+	if status == STATUS_DONE {
+		event = HOOK_ON_DONE
+	}
+
+	now := m.clock.Now()
+	marked := make([]Task, 0, len(ids))
+
+	for _, id := range ids {
+		old := m.data.Tasks[id]
+		task := old
+
+		m.record(&task, now, FIELD_STATUS, task.Status.String(), status.String())
+		task.Status = status
+		task.UpdatedAt = now
+
+		if err := m.runHook(event, &old, &task); err != nil {
+			return nil, err
+		}
+
+		marked = append(marked, task)
+	}
+
+	for _, task := range marked {
+		m.data.Tasks[task.Id] = task
+	}
+
+	return marked, nil
+}
+
+// Deletes many tasks for good
+func (m *Manager) Delete(ids []uint64) ([]Task, error) {
+	if err := m.checkIDs(ids); err != nil {
+		return nil, err
+	}
+
+	deleted := make([]Task, 0, len(ids))
+	for _, id := range ids {
+		task := m.data.Tasks[id]
+		if err := m.runHook(HOOK_ON_DELETE, &task, nil); err != nil {
+			return nil, err
+		}
+
+		deleted = append(deleted, task)
+	}
+
+	for _, id := range ids {
+		delete(m.data.Tasks, id)
+	}
+
+	return deleted, nil
+}
+
+// Deletes every task with the given status
+func (m *Manager) DeleteWhere(status TaskStatus) ([]Task, error) {
+	ids := []uint64{}
+	for _, id := range getSortedIDs(m.data.Tasks) {
+		if m.data.Tasks[id].Status == status {
+			ids = append(ids, id)
+		}
+	}
+
+	return m.Delete(ids)
+}
+
+// Resolves a selector into a sorted list of task IDs
+//
+// Fails if any of the listed IDs doesn't exist, or if nothing was selected
+func (m *Manager) Select(sel Selector) ([]uint64, error) {
+	candidates := sel.IDs
+	if candidates == nil {
+		candidates = getSortedIDs(m.data.Tasks)
+	}
+
+	if err := m.checkIDs(candidates); err != nil {
+		return nil, err
+	}
+
+	ids := []uint64{}
+	for _, id := range candidates {
+		if sel.Matches(m.data.Tasks[id]) {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrNoMatch
+	}
+
+	return ids, nil
+}
+
+// Moves many tasks into the archive
+func (m *Manager) Archive(ids []uint64) ([]Task, error) {
+	if err := m.checkIDs(ids); err != nil {
+		return nil, err
+	}
+
+	archived := make([]Task, 0, len(ids))
+	for _, id := range ids {
+		task := m.data.Tasks[id]
+
+		m.data.Archive[id] = task
+		delete(m.data.Tasks, id)
+
+		archived = append(archived, task)
+	}
+
+	return archived, nil
+}
+
+// Archives all tasks that match the given status and that haven't been
+// updated since the cutoff
+//
+// A nil status matches any status, and a zero cutoff matches any date
+func (m *Manager) ArchiveWhere(status *TaskStatus, cutoff time.Time) []Task {
+	archived := []Task{}
+	for _, id := range getSortedIDs(m.data.Tasks) {
+		task := m.data.Tasks[id]
+
+		if status != nil && task.Status != *status {
+			continue
+		}
+
+		if !cutoff.IsZero() && !task.UpdatedAt.Before(cutoff) {
+			continue
+		}
+
+		m.data.Archive[id] = task
+		delete(m.data.Tasks, id)
+
+		archived = append(archived, task)
+	}
+
+	return archived
+}
+
+// Brings an archived task back
+func (m *Manager) Unarchive(id uint64) (Task, error) {
+	task, err := m.GetArchived(id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	m.data.Tasks[id] = task
+	delete(m.data.Archive, id)
+
+	return task, nil
+}
+
+// Returns the tasks matching the options, sorted and paged
+func (m *Manager) Query(opts QueryOptions) ([]Task, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	source := m.data.Tasks
+	if opts.Archived {
+		source = m.data.Archive
+	}
+
+	return queryTasks(source, opts), nil
+}
+
+func (m *Manager) CountWithStatus(status TaskStatus) int {
+	count := 0
+	for _, task := range m.data.Tasks {
+		if task.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (m *Manager) WipLimit(status TaskStatus) (WipLimit, bool) {
+	limit, ok := m.data.WipLimits[status]
+	return limit, ok
+}
+
+// Sets a status' WIP limit. A limit with a Max of 0 removes it
+func (m *Manager) SetWipLimit(status TaskStatus, limit WipLimit) {
+	if limit.Max <= 0 {
+		delete(m.data.WipLimits, status)
+		return
+	}
+
+	m.data.WipLimits[status] = limit
+}
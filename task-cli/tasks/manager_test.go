@@ -0,0 +1,437 @@
+package tasks
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// A clock that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestManager(t *testing.T) (*Manager, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Date(2024, time.September, 28, 20, 0, 0, 0, time.UTC)}
+
+	m, err := NewManager(NewMemoryStore(), clock)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	return m, clock
+}
+
+func mustAdd(t *testing.T, m *Manager, desc string, tags ...string) Task {
+	t.Helper()
+
+	task, err := m.Add(desc, tags, TaskChanges{})
+	if err != nil {
+		t.Fatalf("Add(%q): %v", desc, err)
+	}
+
+	return task
+}
+
+func TestAddAssignsSequentialIDs(t *testing.T) {
+	m, clock := newTestManager(t)
+
+	for want := uint64(1); want <= 3; want++ {
+		task := mustAdd(t, m, "task")
+		if task.Id != want {
+			t.Errorf("got ID %d, want %d", task.Id, want)
+		}
+
+		if !task.CreatedAt.Equal(clock.now) || task.Status != STATUS_TODO {
+			t.Errorf("unexpected new task: %+v", task)
+		}
+	}
+}
+
+func TestAddReusesFreedIDs(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	for range 3 {
+		mustAdd(t, m, "task")
+	}
+
+	if _, err := m.Delete([]uint64{2}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if task := mustAdd(t, m, "again"); task.Id != 2 {
+		t.Errorf("got ID %d, want the freed ID 2", task.Id)
+	}
+}
+
+func TestAddSkipsArchivedIDs(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	mustAdd(t, m, "one")
+	mustAdd(t, m, "two")
+
+	if _, err := m.Archive([]uint64{1}); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	if task := mustAdd(t, m, "three"); task.Id != 3 {
+		t.Errorf("got ID %d, want 3 (1 is archived)", task.Id)
+	}
+}
+
+func TestAddRejectsEmptyDescription(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	if _, err := m.Add("", nil, TaskChanges{}); !errors.Is(err, ErrEmptyDescription) {
+		t.Errorf("got %v, want ErrEmptyDescription", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	m, clock := newTestManager(t)
+	mustAdd(t, m, "old")
+
+	clock.advance(time.Hour)
+
+	desc := "new"
+	priority := PRIORITY_HIGH
+	if _, err := m.Update([]uint64{1}, TaskChanges{Description: &desc, Priority: &priority}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	task, _ := m.Get(1)
+	if task.Description != "new" || task.Priority != PRIORITY_HIGH {
+		t.Errorf("changes not applied: %+v", task)
+	}
+
+	if !task.UpdatedAt.Equal(clock.now) || task.CreatedAt.Equal(clock.now) {
+		t.Errorf("wrong timestamps: created %v, updated %v", task.CreatedAt, task.UpdatedAt)
+	}
+}
+
+func TestUpdateClearsDue(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	due := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := m.Add("task", nil, TaskChanges{Due: &due}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	zero := time.Time{}
+	if _, err := m.Update([]uint64{1}, TaskChanges{Due: &zero}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if task, _ := m.Get(1); task.Due != nil {
+		t.Errorf("due date not cleared: %v", task.Due)
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	_, err := m.Get(42)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || notFound.Id != 42 {
+		t.Errorf("got %#v, want a NotFoundError for ID 42", err)
+	}
+}
+
+func TestBulkChangesAreAtomic(t *testing.T) {
+	m, _ := newTestManager(t)
+	mustAdd(t, m, "one")
+	mustAdd(t, m, "two")
+
+	if _, err := m.Mark([]uint64{1, 2, 3}, STATUS_DONE, false); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Mark: got %v, want ErrNotFound", err)
+	}
+
+	if _, err := m.Delete([]uint64{1, 3}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Delete: got %v, want ErrNotFound", err)
+	}
+
+	for _, id := range []uint64{1, 2} {
+		task, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("task %d was deleted by a failed bulk change", id)
+		}
+
+		if task.Status != STATUS_TODO {
+			t.Errorf("task %d was marked by a failed bulk change", id)
+		}
+	}
+}
+
+func TestMarkInvalidTransition(t *testing.T) {
+	m, _ := newTestManager(t)
+	mustAdd(t, m, "one")
+	mustAdd(t, m, "two")
+
+	if _, err := m.Mark([]uint64{1}, STATUS_DONE, false); err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+
+	_, err := m.Mark([]uint64{1, 2}, STATUS_DONE, false)
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("got %v, want ErrInvalidTransition", err)
+	}
+
+	if task, _ := m.Get(2); task.Status != STATUS_TODO {
+		t.Errorf("task 2 was marked by a failed bulk change")
+	}
+}
+
+func TestMarkWipLimit(t *testing.T) {
+	m, _ := newTestManager(t)
+	for range 3 {
+		mustAdd(t, m, "task")
+	}
+
+	m.SetWipLimit(STATUS_IN_PROGRESS, WipLimit{Max: 1})
+
+	if _, err := m.Mark([]uint64{1}, STATUS_IN_PROGRESS, false); err != nil {
+		t.Fatalf("Mark within limit: %v", err)
+	}
+
+	if _, err := m.Mark([]uint64{2}, STATUS_IN_PROGRESS, false); !errors.Is(err, ErrWipLimitExceeded) {
+		t.Fatalf("got %v, want ErrWipLimitExceeded", err)
+	}
+
+	if _, err := m.Mark([]uint64{2}, STATUS_IN_PROGRESS, true); err != nil {
+		t.Fatalf("forced Mark: %v", err)
+	}
+
+	m.SetWipLimit(STATUS_IN_PROGRESS, WipLimit{Max: 1, WarnOnly: true})
+
+	if err := m.CheckWipLimit(STATUS_IN_PROGRESS, []uint64{3}); !errors.Is(err, ErrWipLimitExceeded) {
+		t.Errorf("CheckWipLimit: got %v, want ErrWipLimitExceeded", err)
+	}
+
+	if _, err := m.Mark([]uint64{3}, STATUS_IN_PROGRESS, false); err != nil {
+		t.Errorf("warn-only limit refused the change: %v", err)
+	}
+
+	if got := m.CountWithStatus(STATUS_IN_PROGRESS); got != 3 {
+		t.Errorf("got %d in-progress tasks, want 3", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	m, _ := newTestManager(t)
+	mustAdd(t, m, "one", "infra")
+	mustAdd(t, m, "two", "infra", "ops")
+	mustAdd(t, m, "three")
+
+	if _, err := m.Mark([]uint64{2}, STATUS_IN_PROGRESS, false); err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+
+	todo := STATUS_TODO
+	tests := []struct {
+		name string
+		sel  Selector
+		want []uint64
+	}{
+		{"by IDs", Selector{IDs: []uint64{1, 3}}, []uint64{1, 3}},
+		{"by tag", Selector{Tags: []string{"infra"}}, []uint64{1, 2}},
+		{"by tags", Selector{Tags: []string{"infra", "ops"}}, []uint64{2}},
+		{"by status and tag", Selector{Status: &todo, Tags: []string{"infra"}}, []uint64{1}},
+		{"by IDs and status", Selector{IDs: []uint64{2, 3}, Status: &todo}, []uint64{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Select(tt.sel)
+			if err != nil {
+				t.Fatalf("Select: %v", err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Select(Selector{Tags: []string{"nope"}}); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("got %v, want ErrNoMatch", err)
+	}
+
+	if _, err := m.Select(Selector{IDs: []uint64{1, 9}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestArchiveWhere(t *testing.T) {
+	m, clock := newTestManager(t)
+	mustAdd(t, m, "old")
+
+	clock.advance(40 * 24 * time.Hour)
+	mustAdd(t, m, "new")
+
+	if _, err := m.Mark([]uint64{2}, STATUS_DONE, false); err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+
+	archived := m.ArchiveWhere(nil, clock.Now().Add(-30*24*time.Hour))
+	if len(archived) != 1 || archived[0].Id != 1 {
+		t.Fatalf("got %v, want only task 1 archived", archived)
+	}
+
+	done := STATUS_DONE
+	if archived := m.ArchiveWhere(&done, time.Time{}); len(archived) != 1 || archived[0].Id != 2 {
+		t.Fatalf("got %v, want only task 2 archived", archived)
+	}
+
+	if _, err := m.Get(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("archived task is still active")
+	}
+
+	if _, err := m.Unarchive(1); err != nil {
+		t.Fatalf("Unarchive: %v", err)
+	}
+
+	if _, err := m.Get(1); err != nil {
+		t.Errorf("unarchived task isn't active: %v", err)
+	}
+
+	if _, err := m.Unarchive(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	for _, p := range []TaskPriority{PRIORITY_LOW, PRIORITY_HIGH, PRIORITY_MEDIUM, PRIORITY_HIGH} {
+		priority := p
+		if _, err := m.Add("task", nil, TaskChanges{Priority: &priority}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	got, err := m.Query(QueryOptions{SortBy: "priority", Descending: true})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	// Ties keep ID order, even when descending
+	if ids := taskIDs(got); !slices.Equal(ids, []uint64{2, 4, 3, 1}) {
+		t.Errorf("got %v, want [2 4 3 1]", ids)
+	}
+
+	got, err = m.Query(QueryOptions{Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	if ids := taskIDs(got); !slices.Equal(ids, []uint64{2, 3}) {
+		t.Errorf("got %v, want [2 3]", ids)
+	}
+
+	if _, err := m.Query(QueryOptions{SortBy: "nope"}); err == nil {
+		t.Errorf("expected an error for an unknown sort field")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	m, clock := newTestManager(t)
+	m.SetActor("pedro")
+
+	mustAdd(t, m, "old")
+
+	clock.advance(time.Minute)
+	desc := "new"
+	if _, err := m.Update([]uint64{1}, TaskChanges{Description: &desc}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// Changes that don't touch the description or status aren't recorded
+	priority := PRIORITY_LOW
+	if _, err := m.Update([]uint64{1}, TaskChanges{Priority: &priority}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	clock.advance(time.Minute)
+	if _, err := m.Mark([]uint64{1}, STATUS_IN_PROGRESS, false); err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+
+	task, _ := m.Get(1)
+	want := []Change{
+		{Field: FIELD_CREATED, To: "old"},
+		{Field: FIELD_DESCRIPTION, From: "old", To: "new"},
+		{Field: FIELD_STATUS, From: "To-do", To: "In Progress"},
+	}
+
+	if len(task.History) != len(want) {
+		t.Fatalf("got %d history entries, want %d: %v", len(task.History), len(want), task.History)
+	}
+
+	for idx, change := range task.History {
+		if change.Field != want[idx].Field || change.From != want[idx].From || change.To != want[idx].To {
+			t.Errorf("entry %d: got %+v, want %+v", idx, change, want[idx])
+		}
+
+		if change.Actor != "pedro" {
+			t.Errorf("entry %d: got actor %q, want \"pedro\"", idx, change.Actor)
+		}
+	}
+
+	if !task.History[2].At.Equal(clock.now) {
+		t.Errorf("status change recorded at %v, want %v", task.History[2].At, clock.now)
+	}
+}
+
+func TestSaveAndReload(t *testing.T) {
+	store := NewMemoryStore()
+
+	m, err := NewManager(store, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	mustAdd(t, m, "kept", "tag")
+	m.SetWipLimit(STATUS_IN_PROGRESS, WipLimit{Max: 2})
+
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := NewManager(store, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	task, err := reloaded.Get(1)
+	if err != nil || task.Description != "kept" || !task.HasTag("tag") {
+		t.Errorf("got %+v, %v after reloading", task, err)
+	}
+
+	if limit, ok := reloaded.WipLimit(STATUS_IN_PROGRESS); !ok || limit.Max != 2 {
+		t.Errorf("WIP limit lost after reloading")
+	}
+}
+
+func taskIDs(list []Task) []uint64 {
+	ids := make([]uint64, 0, len(list))
+	for _, task := range list {
+		ids = append(ids, task.Id)
+	}
+
+	return ids
+}
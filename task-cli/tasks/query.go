@@ -0,0 +1,243 @@
+package tasks
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Options controlling which tasks are listed, and in which order
+type QueryOptions struct {
+	Archived bool
+	Status   *TaskStatus
+
+	// Only tasks estimated within these bounds (in the same unit) are listed
+	MinEstimate *Estimate
+	MaxEstimate *Estimate
+
+	// Only tasks without an estimate are listed
+	Unestimated bool
+
+	SortBy     string
+	Descending bool
+
+	Limit  int
+	Offset int
+}
+
+type TaskComparator func(a, b Task) int
+
+// Compares due dates, with tasks that have no due date coming last
+func compareDue(a, b Task) int {
+	switch {
+	case a.Due == nil && b.Due == nil:
+		return 0
+	case a.Due == nil:
+		return 1
+	case b.Due == nil:
+		return -1
+	default:
+		return a.Due.Compare(*b.Due)
+	}
+}
+
+var Comparators map[string]TaskComparator = map[string]TaskComparator{
+	"id": func(a, b Task) int {
+		return cmp.Compare(a.Id, b.Id)
+	},
+	"created": func(a, b Task) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	},
+	"updated": func(a, b Task) int {
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	},
+	"due":      compareDue,
+	"estimate": compareEstimate,
+	"priority": func(a, b Task) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	},
+	"status": func(a, b Task) int {
+		return cmp.Compare(a.Status, b.Status)
+	},
+	"description": func(a, b Task) int {
+		return strings.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description))
+	},
+}
+
+const (
+	NO_TAG     = "(no tag)"
+	NO_PROJECT = "(no project)"
+	NO_DUE     = "(no due date)"
+)
+
+// Returns the groups a task belongs to. Tasks with many tags go in many groups
+type TaskGrouper func(t Task) []string
+
+var Groupers map[string]TaskGrouper = map[string]TaskGrouper{
+	"status": func(t Task) []string {
+		return []string{t.Status.String()}
+	},
+	"tag": func(t Task) []string {
+		if len(t.Tags) == 0 {
+			return []string{NO_TAG}
+		}
+
+		return t.Tags
+	},
+	"project": func(t Task) []string {
+		if t.Project == "" {
+			return []string{NO_PROJECT}
+		}
+
+		return []string{t.Project}
+	},
+	"due-week": func(t Task) []string {
+		if t.Due == nil {
+			return []string{NO_DUE}
+		}
+
+		year, week := t.Due.ISOWeek()
+		return []string{fmt.Sprintf("%d-W%02d", year, week)}
+	},
+}
+
+func (o QueryOptions) Validate() error {
+	if _, ok := Comparators[o.SortBy]; o.SortBy != "" && !ok {
+		return fmt.Errorf(
+			"Can't sort by '%s' (must be id, created, updated, due, estimate, priority, status or description)",
+			o.SortBy,
+		)
+	}
+
+	if o.Unestimated && (o.MinEstimate != nil || o.MaxEstimate != nil) {
+		return fmt.Errorf("Can't filter by estimate and list unestimated tasks at once")
+	}
+
+	if o.MinEstimate != nil && o.MaxEstimate != nil && o.MinEstimate.Unit != o.MaxEstimate.Unit {
+		return fmt.Errorf("The minimum and maximum estimates must be in the same unit")
+	}
+
+	if o.Limit < 0 || o.Offset < 0 {
+		return fmt.Errorf("--limit and --offset can't be negative")
+	}
+
+	return nil
+}
+
+func (o QueryOptions) matchesEstimate(t Task) bool {
+	if o.Unestimated {
+		return t.Estimate == nil
+	}
+
+	for _, bound := range []*Estimate{o.MinEstimate, o.MaxEstimate} {
+		if bound != nil && (t.Estimate == nil || t.Estimate.Unit != bound.Unit) {
+			return false
+		}
+	}
+
+	if o.MinEstimate != nil && t.Estimate.Amount < o.MinEstimate.Amount {
+		return false
+	}
+
+	return o.MaxEstimate == nil || t.Estimate.Amount <= o.MaxEstimate.Amount
+}
+
+// Filters, sorts and pages a list of tasks
+func queryTasks(source map[uint64]Task, opts QueryOptions) []Task {
+	list := make([]Task, 0, len(source))
+	for _, task := range source {
+		if opts.Status != nil && task.Status != *opts.Status {
+			continue
+		}
+
+		if !opts.matchesEstimate(task) {
+			continue
+		}
+
+		list = append(list, task)
+	}
+
+	// Sorting by ID first keeps ties in a predictable order
+	slices.SortFunc(list, Comparators["id"])
+
+	if compare, ok := Comparators[opts.SortBy]; ok {
+		slices.SortStableFunc(list, func(a, b Task) int {
+			if opts.Descending {
+				return compare(b, a)
+			}
+
+			return compare(a, b)
+		})
+	}
+
+	if opts.Offset >= len(list) {
+		return []Task{}
+	}
+
+	list = list[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(list) {
+		list = list[:opts.Limit]
+	}
+
+	return list
+}
+
+// A named group of tasks
+type Group struct {
+	Name  string
+	Tasks []Task
+}
+
+// Orders group names alphabetically, with the "no value" groups coming last
+func compareGroupNames(a, b string) int {
+	noA, noB := strings.HasPrefix(a, "("), strings.HasPrefix(b, "(")
+	if noA != noB {
+		if noA {
+			return 1
+		}
+
+		return -1
+	}
+
+	return strings.Compare(a, b)
+}
+
+// Splits tasks into groups, keeping each group's tasks in list order
+//
+// Status groups follow the status order, and every other kind of group is
+// sorted by name
+func GroupTasks(list []Task, groupBy string) ([]Group, error) {
+	grouper, ok := Groupers[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("Can't group by '%s' (must be status, tag, project or due-week)", groupBy)
+	}
+
+	groups := []Group{}
+	index := map[string]int{}
+
+	for _, task := range list {
+		for _, name := range grouper(task) {
+			idx, ok := index[name]
+			if !ok {
+				idx = len(groups)
+				index[name] = idx
+				groups = append(groups, Group{Name: name})
+			}
+
+			groups[idx].Tasks = append(groups[idx].Tasks, task)
+		}
+	}
+
+	if groupBy == "status" {
+		slices.SortFunc(groups, func(a, b Group) int {
+			return cmp.Compare(a.Tasks[0].Status, b.Tasks[0].Status)
+		})
+	} else {
+		slices.SortFunc(groups, func(a, b Group) int {
+			return compareGroupNames(a.Name, b.Name)
+		})
+	}
+
+	return groups, nil
+}
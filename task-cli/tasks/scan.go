@@ -0,0 +1,378 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Where a task came from, for tasks created from TODO comments
+type SourceRef struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+func (s SourceRef) Location() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+const (
+	// Files bigger than this are skipped when scanning
+	MAX_SCAN_SIZE = 1 << 20
+)
+
+var (
+	cStyle    []string = []string{"//", "/*"}
+	hashStyle []string = []string{"#"}
+	dashStyle []string = []string{"--"}
+	lispStyle []string = []string{";"}
+	xmlStyle  []string = []string{"<!--"}
+	cssStyle  []string = []string{"/*"}
+)
+
+// How comments start, by file extension
+var commentMarkers map[string][]string = map[string][]string{
+	".go": cStyle, ".c": cStyle, ".h": cStyle, ".cc": cStyle, ".cpp": cStyle,
+	".hpp": cStyle, ".java": cStyle, ".js": cStyle, ".jsx": cStyle,
+	".ts": cStyle, ".tsx": cStyle, ".rs": cStyle, ".swift": cStyle,
+	".kt": cStyle, ".cs": cStyle, ".scala": cStyle, ".php": cStyle,
+	".dart": cStyle, ".zig": cStyle,
+
+	".py": hashStyle, ".rb": hashStyle, ".sh": hashStyle, ".bash": hashStyle,
+	".zsh": hashStyle, ".pl": hashStyle, ".r": hashStyle, ".ex": hashStyle,
+	".exs": hashStyle, ".yaml": hashStyle, ".yml": hashStyle,
+	".toml": hashStyle, ".tf": hashStyle, ".cmake": hashStyle,
+
+	".sql": dashStyle, ".lua": dashStyle, ".hs": dashStyle,
+
+	".lisp": lispStyle, ".clj": lispStyle, ".el": lispStyle, ".asm": lispStyle,
+
+	".html": xmlStyle, ".xml": xmlStyle, ".vue": xmlStyle,
+
+	".css": cssStyle, ".scss": cssStyle, ".less": cssStyle,
+}
+
+// Files without an extension that still have comments
+var commentMarkersByName map[string][]string = map[string][]string{
+	"Makefile":   hashStyle,
+	"Dockerfile": hashStyle,
+}
+
+var todoPattern *regexp.Regexp = regexp.MustCompile(`\b(TODO|FIXME|HACK)\b(?:\([^)]*\))?:?\s*(.*)`)
+
+func getCommentMarkers(name string) []string {
+	if markers, ok := commentMarkersByName[name]; ok {
+		return markers
+	}
+
+	return commentMarkers[strings.ToLower(filepath.Ext(name))]
+}
+
+// Returns the comment part of a line, if it has one
+func findComment(line string, markers []string) (string, bool) {
+	start := -1
+	for _, marker := range markers {
+		if idx := strings.Index(line, marker); idx >= 0 && (start < 0 || idx < start) {
+			start = idx
+		}
+	}
+
+	if start >= 0 {
+		return line[start:], true
+	}
+
+	// Lines inside /* */ blocks usually start with a star
+	if markers[len(markers)-1] == "/*" && strings.HasPrefix(strings.TrimSpace(line), "*") {
+		return strings.TrimSpace(line), true
+	}
+
+	return "", false
+}
+
+// Finds the TODO, FIXME and HACK comments in a single file
+func scanFile(path string, markers []string) ([]SourceRef, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary files have no comments worth reading
+	if bytes.IndexByte(file[:min(len(file), 8000)], 0) >= 0 {
+		return nil, nil
+	}
+
+	found := []SourceRef{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(file))
+	scanner.Buffer(make([]byte, 0, 64*1024), MAX_SCAN_SIZE)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		comment, ok := findComment(scanner.Text(), markers)
+		if !ok {
+			continue
+		}
+
+		match := todoPattern.FindStringSubmatch(comment)
+		if match == nil {
+			continue
+		}
+
+		text := strings.TrimSpace(match[2])
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(text, "*/"), "-->"))
+
+		found = append(found, SourceRef{
+			File: path,
+			Line: lineNum,
+			Kind: match[1],
+			Text: text,
+		})
+	}
+
+	return found, scanner.Err()
+}
+
+// Walks a directory tree, finding TODO, FIXME and HACK comments in the files
+// of languages it knows about
+//
+// Files are given by their absolute paths, so that scanning the same tree by
+// another path finds the same comments. Files and directories ignored by git
+// are skipped (by .gitignore files, including the ones above the tree, and
+// .git/info/exclude), and so is .git itself
+func ScanDir(root string) ([]SourceRef, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := newGitignore(root)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []SourceRef{}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := ignore.rel(path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path == root {
+				return ignore.load(rel)
+			}
+
+			if d.Name() == ".git" || ignore.ignored(rel, true) {
+				return filepath.SkipDir
+			}
+
+			return ignore.load(rel)
+		}
+
+		if !d.Type().IsRegular() || ignore.ignored(rel, false) {
+			return nil
+		}
+
+		markers := getCommentMarkers(d.Name())
+		if markers == nil {
+			return nil
+		}
+
+		if info, err := d.Info(); err != nil || info.Size() > MAX_SCAN_SIZE {
+			return nil
+		}
+
+		comments, err := scanFile(path, markers)
+		if err != nil {
+			return err
+		}
+
+		found = append(found, comments...)
+		return nil
+	})
+
+	return found, err
+}
+
+// What changed when syncing a scan with the tasks
+type ScanResult struct {
+	Added     []Task
+	Moved     []Task
+	Closed    []Task
+	Unchanged int
+}
+
+// Returns the absolute path of a scanned file. Older scans stored paths as
+// they were typed, which are taken to be relative to the working directory
+func absSource(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(file)
+}
+
+// Returns whether a file is inside a directory (or is the directory)
+func isUnder(file, dir string) bool {
+	rel, err := filepath.Rel(dir, file)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func describeComment(c SourceRef) string {
+	if c.Text != "" {
+		return c.Text
+	}
+
+	return fmt.Sprintf("%s at %s", c.Kind, c.Location())
+}
+
+// Brings the tasks created from a tree's comments up to date with a new scan
+// of it
+//
+// Comments are matched to tasks by location and text first, and then by text
+// alone (for comments that moved around in their file). New comments become
+// new tasks, and tasks whose comments are gone are marked as done. If a hook
+// refuses any of it, nothing changes
+func (m *Manager) SyncComments(root string, found []SourceRef) (ScanResult, error) {
+	result := ScanResult{}
+	err := m.allOrNothing(func() error {
+		var err error
+		result, err = m.syncComments(root, found)
+		return err
+	})
+
+	if err != nil {
+		return ScanResult{}, err
+	}
+
+	return result, nil
+}
+
+func (m *Manager) syncComments(root string, found []SourceRef) (ScanResult, error) {
+	result := ScanResult{}
+	root = absSource(root)
+
+	type key struct {
+		file, kind, text string
+	}
+
+	// Archived tasks are matched too, so that they don't come back, but only
+	// active ones are ever closed
+	existing := map[key][]Task{}
+	for _, source := range []map[uint64]Task{m.data.Tasks, m.data.Archive} {
+		for _, id := range getSortedIDs(source) {
+			task := source[id]
+			if task.Source == nil {
+				continue
+			}
+
+			file := absSource(task.Source.File)
+			if !isUnder(file, root) {
+				continue
+			}
+
+			k := key{file, task.Source.Kind, task.Source.Text}
+			existing[k] = append(existing[k], task)
+		}
+	}
+
+	matched := map[uint64]bool{}
+	unmatched := []SourceRef{}
+
+	// Exact matches first, so that they can't be taken by moved comments
+	for _, c := range found {
+		c.File = absSource(c.File)
+		k := key{c.File, c.Kind, c.Text}
+
+		exact := false
+		for _, task := range existing[k] {
+			if !matched[task.Id] && task.Source.Line == c.Line {
+				matched[task.Id] = true
+				exact = true
+				result.Unchanged++
+				break
+			}
+		}
+
+		if !exact {
+			unmatched = append(unmatched, c)
+		}
+	}
+
+	now := m.clock.Now()
+	added := []SourceRef{}
+
+	for _, c := range unmatched {
+		moved := false
+		for _, task := range existing[key{c.File, c.Kind, c.Text}] {
+			if matched[task.Id] {
+				continue
+			}
+
+			matched[task.Id] = true
+			moved = true
+
+			source := c
+			task.Source = &source
+			task.UpdatedAt = now
+
+			if _, ok := m.data.Tasks[task.Id]; ok {
+				m.data.Tasks[task.Id] = task
+			} else {
+				m.data.Archive[task.Id] = task
+			}
+
+			result.Moved = append(result.Moved, task)
+			break
+		}
+
+		if !moved {
+			added = append(added, c)
+		}
+	}
+
+	toClose := []uint64{}
+	for _, tasks := range existing {
+		for _, task := range tasks {
+			if matched[task.Id] || task.Status == STATUS_DONE {
+				continue
+			}
+
+			if _, ok := m.data.Tasks[task.Id]; ok {
+				toClose = append(toClose, task.Id)
+			}
+		}
+	}
+
+	if len(toClose) > 0 {
+		closed, err := m.Mark(toClose, STATUS_DONE, true)
+		if err != nil {
+			return result, err
+		}
+
+		result.Closed = closed
+	}
+
+	for _, c := range added {
+		source := c
+		task, err := m.Add(describeComment(c), []string{strings.ToLower(c.Kind)}, TaskChanges{Source: &source})
+		if err != nil {
+			return result, err
+		}
+
+		result.Added = append(result.Added, task)
+	}
+
+	return result, nil
+}
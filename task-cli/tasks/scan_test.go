@@ -0,0 +1,244 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScanDir(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":      "vendor/\n*.gen.go\n!keep.gen.go\n",
+		"main.go":         "package main\n\n// TODO: parse flags\nfunc main() {} // FIXME(pb) exits 0\nvar todo = \"TODO not a comment\"\n",
+		"lib/util.py":     "x = 1\n# HACK work around the API\n",
+		"lib/.gitignore":  "/skip.py\n",
+		"lib/skip.py":     "# TODO ignored by lib/.gitignore\n",
+		"vendor/dep.go":   "// TODO ignored directory\n",
+		"out.gen.go":      "// TODO ignored file\n",
+		"keep.gen.go":     "// TODO kept by a negation\n",
+		"notes.txt":       "TODO unknown file type\n",
+		".git/hooks/x.sh": "# TODO inside .git\n",
+		"style/site.css":  "/*\n * TODO: dark mode\n */\n",
+	})
+
+	found, err := ScanDir(root)
+	if err != nil {
+		t.Fatalf("ScanDir: %v", err)
+	}
+
+	want := map[string]string{
+		"main.go:3":        "TODO parse flags",
+		"main.go:4":        "FIXME exits 0",
+		"lib/util.py:2":    "HACK work around the API",
+		"keep.gen.go:1":    "TODO kept by a negation",
+		"style/site.css:2": "TODO dark mode",
+	}
+
+	got := map[string]string{}
+	for _, c := range found {
+		rel, _ := filepath.Rel(root, c.File)
+		got[filepath.ToSlash(rel)+":"+strconv.Itoa(c.Line)] = c.Kind + " " + c.Text
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for loc, text := range want {
+		if got[loc] != text {
+			t.Errorf("%s: got %q, want %q", loc, got[loc], text)
+		}
+	}
+}
+
+func TestSyncCommentsIsIdempotent(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a.go": "// TODO first\n// TODO second\n",
+	})
+
+	scan := func() ScanResult {
+		t.Helper()
+
+		found, err := ScanDir(root)
+		if err != nil {
+			t.Fatalf("ScanDir: %v", err)
+		}
+
+		result, err := m.SyncComments(root, found)
+		if err != nil {
+			t.Fatalf("SyncComments: %v", err)
+		}
+
+		return result
+	}
+
+	if result := scan(); len(result.Added) != 2 {
+		t.Fatalf("got %d added, want 2", len(result.Added))
+	}
+
+	if result := scan(); len(result.Added) != 0 || result.Unchanged != 2 {
+		t.Fatalf("rescan changed things: %+v", result)
+	}
+
+	// The first comment moves down, the second one goes away and a new one
+	// shows up
+	writeFiles(t, root, map[string]string{
+		"a.go": "package a\n\n// TODO first\n// TODO third\n",
+	})
+
+	result := scan()
+	if len(result.Moved) != 1 || result.Moved[0].Id != 1 || result.Moved[0].Source.Line != 3 {
+		t.Errorf("unexpected moved tasks: %+v", result.Moved)
+	}
+
+	if len(result.Closed) != 1 || result.Closed[0].Id != 2 || result.Closed[0].Status != STATUS_DONE {
+		t.Errorf("unexpected closed tasks: %+v", result.Closed)
+	}
+
+	if len(result.Added) != 1 || result.Added[0].Description != "third" || !result.Added[0].HasTag("todo") {
+		t.Errorf("unexpected added tasks: %+v", result.Added)
+	}
+
+	if ids := m.IDs(); len(ids) != 3 {
+		t.Errorf("got tasks %v, want 3 of them", ids)
+	}
+}
+
+func TestSyncCommentsWithOtherRoots(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"src/a.go": "// TODO first\n",
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// The same tree, by a relative path, an absolute one and from inside it
+	scans := []struct{ dir, root string }{
+		{root, "src"},
+		{root, filepath.Join(root, "src")},
+		{filepath.Join(root, "src"), "."},
+		{root, "./src/../src"},
+	}
+
+	for idx, scan := range scans {
+		if err := os.Chdir(scan.dir); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := ScanDir(scan.root)
+		if err != nil {
+			t.Fatalf("ScanDir(%q): %v", scan.root, err)
+		}
+
+		result, err := m.SyncComments(scan.root, found)
+		if err != nil {
+			t.Fatalf("SyncComments(%q): %v", scan.root, err)
+		}
+
+		if idx == 0 {
+			continue
+		}
+
+		if len(result.Added) != 0 || len(result.Closed) != 0 || result.Unchanged != 1 {
+			t.Errorf("scanning %q from %q changed things: %+v", scan.root, scan.dir, result)
+		}
+	}
+
+	if ids := m.IDs(); len(ids) != 1 {
+		t.Errorf("got tasks %v, want just one", ids)
+	}
+}
+
+func TestScanDirInRepository(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude":  "*.local.go\n",
+		".gitignore":         "build/\n/src/gen.go\n",
+		"src/.gitignore":     "*.tmp.go\n",
+		"src/lib/a.go":       "// TODO kept\n",
+		"src/lib/b.local.go": "// TODO excluded\n",
+		"src/lib/c.tmp.go":   "// TODO ignored by src/.gitignore\n",
+		"src/lib/build/d.go": "// TODO ignored by the top .gitignore\n",
+		"src/gen.go":         "// TODO outside of the scan\n",
+	})
+
+	found, err := ScanDir(filepath.Join(root, "src", "lib"))
+	if err != nil {
+		t.Fatalf("ScanDir: %v", err)
+	}
+
+	if len(found) != 1 || found[0].File != filepath.Join(root, "src", "lib", "a.go") {
+		t.Errorf("got %+v, want just src/lib/a.go", found)
+	}
+}
+
+func TestSyncCommentsHookVeto(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a.go": "// TODO first\n",
+	})
+
+	found, err := ScanDir(root)
+	if err != nil {
+		t.Fatalf("ScanDir: %v", err)
+	}
+
+	if _, err := m.SyncComments(root, found); err != nil {
+		t.Fatalf("SyncComments: %v", err)
+	}
+
+	// The first comment goes away and a new one shows up, but the hook
+	// refuses the new task after the old one was closed
+	writeFiles(t, root, map[string]string{
+		"a.go": "// TODO second\n",
+	})
+
+	found, err = ScanDir(root)
+	if err != nil {
+		t.Fatalf("ScanDir: %v", err)
+	}
+
+	m.SetHooks(&fakeHooks{veto: 2})
+
+	if _, err := m.SyncComments(root, found); !errors.Is(err, ErrHookVeto) {
+		t.Fatalf("got %v, want ErrHookVeto", err)
+	}
+
+	if task, _ := m.Get(1); task.Status != STATUS_TODO {
+		t.Errorf("task 1 was closed despite the veto: %+v", task)
+	}
+
+	if ids := m.IDs(); len(ids) != 1 {
+		t.Errorf("got tasks %v, want just the first one", ids)
+	}
+}
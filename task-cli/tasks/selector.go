@@ -0,0 +1,105 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The most IDs a single range can cover, so that a typo like 1-999999999
+	// doesn't eat all the memory
+	MAX_ID_RANGE = 10000
+)
+
+// Selects a set of tasks, either by ID, by query, or both
+//
+// When both are given, the selected tasks are the ones listed by ID that
+// also match the query
+type Selector struct {
+	IDs    []uint64
+	Status *TaskStatus
+	Tags   []string
+}
+
+func (s Selector) Matches(task Task) bool {
+	if s.Status != nil && task.Status != *s.Status {
+		return false
+	}
+
+	for _, tag := range s.Tags {
+		if !task.HasTag(tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func ParseID(id string) (uint64, error) {
+	if id == "" {
+		return 0, errors.New("Must provide task ID")
+	}
+
+	idNum, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Couldn't convert ID '%v' to number!", id)
+	}
+
+	return idNum, nil
+}
+
+// Parses a list of IDs and ID ranges, such as "3,5,8-12"
+func ParseIDList(list string) ([]uint64, error) {
+	if list == "" {
+		return nil, errors.New("Must provide task ID")
+	}
+
+	ids := []uint64{}
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			id, err := ParseID(part)
+			if err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, id)
+			continue
+		}
+
+		start, err := ParseID(from)
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := ParseID(to)
+		if err != nil {
+			return nil, err
+		}
+
+		if start > end {
+			return nil, fmt.Errorf("Invalid ID range '%s' (start is after end)!", part)
+		}
+
+		if end-start >= MAX_ID_RANGE {
+			return nil, fmt.Errorf("ID range '%s' is too big (can cover at most %d IDs)!", part, MAX_ID_RANGE)
+		}
+
+		// Stops at the end rather than past it, which wraps around at the
+		// largest ID
+		for id := start; ; id++ {
+			ids = append(ids, id)
+			if id == end {
+				break
+			}
+		}
+	}
+
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
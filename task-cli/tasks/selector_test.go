@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestParseIDList(t *testing.T) {
+	tests := []struct {
+		list string
+		want []uint64
+	}{
+		{"1", []uint64{1}},
+		{"3,5,8-12", []uint64{3, 5, 8, 9, 10, 11, 12}},
+		{"5, 3", []uint64{3, 5}},
+		{"1-3,2-4", []uint64{1, 2, 3, 4}},
+		{"18446744073709551614-18446744073709551615", []uint64{math.MaxUint64 - 1, math.MaxUint64}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseIDList(tt.list)
+		if err != nil {
+			t.Errorf("ParseIDList(%q): %v", tt.list, err)
+			continue
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseIDList(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestParseIDListErrors(t *testing.T) {
+	for _, list := range []string{"", "a", "1,,2", "5-3", "1-", "-1", "1-999999999", "1-10001"} {
+		if got, err := ParseIDList(list); err == nil {
+			t.Errorf("ParseIDList(%q) = %v, want an error", list, got)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// Everything task-cli persists
+type Data struct {
+	Tasks     map[uint64]Task         `json:"tasks"`
+	Archive   map[uint64]Task         `json:"archive,omitempty"`
+	WipLimits map[TaskStatus]WipLimit `json:"wipLimits,omitempty"`
+}
+
+func NewData() *Data {
+	return &Data{
+		Tasks:     make(map[uint64]Task, 0),
+		Archive:   make(map[uint64]Task, 0),
+		WipLimits: make(map[TaskStatus]WipLimit, 0),
+	}
+}
+
+// Returns a copy of the data that can be changed without touching it
+func (d *Data) clone() *Data {
+	return &Data{
+		Tasks:     maps.Clone(d.Tasks),
+		Archive:   maps.Clone(d.Archive),
+		WipLimits: maps.Clone(d.WipLimits),
+	}
+}
+
+// Fills in maps left out by older (or emptier) databases
+func (d *Data) normalize() {
+	if d.Tasks == nil {
+		d.Tasks = make(map[uint64]Task, 0)
+	}
+
+	if d.Archive == nil {
+		d.Archive = make(map[uint64]Task, 0)
+	}
+
+	if d.WipLimits == nil {
+		d.WipLimits = make(map[TaskStatus]WipLimit, 0)
+	}
+}
+
+// Somewhere tasks are kept between runs
+type Store interface {
+	// Returns the saved data, or empty data if nothing was saved yet
+	Load() (*Data, error)
+	Save(data *Data) error
+}
+
+// Keeps tasks in memory only. Useful for tests, or for short-lived managers
+type MemoryStore struct {
+	saved []byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Load() (*Data, error) {
+	data := NewData()
+	if s.saved == nil {
+		return data, nil
+	}
+
+	// Going through JSON keeps the saved copy from sharing memory with the
+	// manager's, just like a file would
+	if err := json.Unmarshal(s.saved, data); err != nil {
+		return nil, err
+	}
+
+	data.normalize()
+	return data, nil
+}
+
+func (s *MemoryStore) Save(data *Data) error {
+	saved, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.saved = saved
+	return nil
+}
+
+// Asks for the passphrase of an encrypted database. When confirm is set, it's
+// for a new passphrase, and should be asked twice
+type PassphraseFunc func(confirm bool) ([]byte, error)
+
+// Keeps tasks in a JSON file, optionally encrypted with a passphrase
+type FileStore struct {
+	Path       string
+	Passphrase PassphraseFunc
+
+	key *dbKey
+}
+
+func NewFileStore(path string, passphrase PassphraseFunc) *FileStore {
+	return &FileStore{
+		Path:       path,
+		Passphrase: passphrase,
+	}
+}
+
+func (s *FileStore) Load() (*Data, error) {
+	data := NewData()
+
+	file, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return data, nil
+		}
+
+		return nil, err
+	}
+
+	file, err = s.decrypt(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(file, data); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling JSON data: %v", err)
+	}
+
+	data.normalize()
+	return data, nil
+}
+
+// Saves the data, readable only by its owner
+func (s *FileStore) Save(data *Data) error {
+	file, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Error marshalling JSON data: %v", err)
+	}
+
+	if s.key != nil {
+		file, err = s.key.encrypt(file)
+		if err != nil {
+			return fmt.Errorf("Error encrypting JSON data: %v", err)
+		}
+	}
+
+	// The data goes to a new file (which CreateTemp makes readable only by
+	// its owner) that then replaces the old one, so it's never readable by
+	// others, even when an older database was, and a failed save doesn't
+	// leave half a database behind
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(file); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (s *FileStore) Encrypted() bool {
+	return s.key != nil
+}
+
+// Turns on encryption with a new passphrase, starting on the next save
+func (s *FileStore) Encrypt(passphrase []byte) error {
+	if s.key != nil {
+		return errors.New("The database is already encrypted!")
+	}
+
+	key, err := newKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	s.key = key
+	return nil
+}
+
+// Turns off encryption, starting on the next save
+func (s *FileStore) Decrypt() error {
+	if s.key == nil {
+		return errors.New("The database isn't encrypted!")
+	}
+
+	s.key = nil
+	return nil
+}
+
+// Decrypts the file if it's encrypted, asking for the passphrase
+//
+// Plaintext files are returned as-is
+func (s *FileStore) decrypt(file []byte) ([]byte, error) {
+	var db EncryptedDB
+	if err := json.Unmarshal(file, &db); err != nil || db.Encryption == nil {
+		return file, nil
+	}
+
+	// Reloading a database sealed with the same key doesn't ask again
+	if s.key != nil && s.key.matches(*db.Encryption) {
+		return s.key.decrypt(db)
+	}
+
+	if s.Passphrase == nil {
+		return nil, errors.New("The database is encrypted, but no passphrase was given")
+	}
+
+	pass, err := s.Passphrase(false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(pass, *db.Encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := key.decrypt(db)
+	if err != nil {
+		return nil, err
+	}
+
+	s.key = key
+	return plaintext, nil
+}
@@ -0,0 +1,219 @@
+package tasks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func staticPassphrase(pass string) PassphraseFunc {
+	return func(confirm bool) ([]byte, error) {
+		return []byte(pass), nil
+	}
+}
+
+func TestFileStoreMissingFile(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "db.json"), nil)
+
+	data, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(data.Tasks) != 0 {
+		t.Errorf("got %d tasks from a missing file", len(data.Tasks))
+	}
+}
+
+func TestFileStorePermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	if err := os.WriteFile(path, []byte(`{"tasks":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewFileStore(path, nil)
+	data, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if err := store.Save(data); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("got permissions %o, want 600", perm)
+	}
+
+	if leftovers, _ := filepath.Glob(path + ".*.tmp"); len(leftovers) != 0 {
+		t.Errorf("saving left %v behind", leftovers)
+	}
+}
+
+func TestFileStoreEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+
+	store := NewFileStore(path, nil)
+	m, err := NewManager(store, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	mustAdd(t, m, "a secret incident")
+
+	if err := store.Encrypt([]byte("hunter2")); err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(file), "secret") {
+		t.Errorf("the encrypted database contains plaintext")
+	}
+
+	if _, err := NewFileStore(path, staticPassphrase("wrong")).Load(); err == nil {
+		t.Errorf("loaded the database with the wrong passphrase")
+	}
+
+	if _, err := NewFileStore(path, nil).Load(); err == nil {
+		t.Errorf("loaded the database without a passphrase")
+	}
+
+	reopened := NewFileStore(path, staticPassphrase("hunter2"))
+	reloaded, err := NewManager(reopened, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	if task, err := reloaded.Get(1); err != nil || task.Description != "a secret incident" {
+		t.Errorf("got %+v, %v after decrypting", task, err)
+	}
+
+	if !reopened.Encrypted() {
+		t.Errorf("reopened store doesn't know it's encrypted")
+	}
+
+	if err := reopened.Decrypt(); err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+
+	if err := reloaded.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if _, err := NewFileStore(path, nil).Load(); err != nil {
+		t.Errorf("couldn't load the decrypted database: %v", err)
+	}
+}
+
+func TestReloadEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+
+	writer := NewFileStore(path, nil)
+	if err := writer.Encrypt([]byte("hunter2")); err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	w, err := NewManager(writer, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	if err := w.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	asked := 0
+	reader := NewFileStore(path, func(confirm bool) ([]byte, error) {
+		asked++
+		return []byte("hunter2"), nil
+	})
+
+	r, err := NewManager(reader, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	mustAdd(t, w, "added elsewhere")
+	if err := w.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if ids := r.IDs(); len(ids) != 1 {
+		t.Errorf("got tasks %v after reloading, want 1", ids)
+	}
+
+	if asked != 1 {
+		t.Errorf("asked for the passphrase %d times, want 1", asked)
+	}
+}
+
+func TestDamagedEncryptedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+
+	store := NewFileStore(path, nil)
+	if err := store.Encrypt([]byte("hunter2")); err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	if err := store.Save(NewData()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var saved EncryptedDB
+	if err := json.Unmarshal(file, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	damage := map[string]func(p *EncryptionParams){
+		"truncated nonce": func(p *EncryptionParams) { p.Nonce = p.Nonce[:4] },
+		"missing nonce":   func(p *EncryptionParams) { p.Nonce = nil },
+		"huge N":          func(p *EncryptionParams) { p.N = 1 << 30 },
+		"zero N":          func(p *EncryptionParams) { p.N = 0 },
+		"huge r":          func(p *EncryptionParams) { p.R = 1 << 20 },
+		"huge p":          func(p *EncryptionParams) { p.P = 1 << 20 },
+		"too much memory": func(p *EncryptionParams) { p.N, p.R = MAX_SCRYPT_N, MAX_SCRYPT_R },
+		"missing salt":    func(p *EncryptionParams) { p.Salt = nil },
+	}
+
+	for name, damageParams := range damage {
+		params := *saved.Encryption
+		damageParams(&params)
+
+		damaged, err := json.Marshal(EncryptedDB{Encryption: &params, Data: saved.Data})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, damaged, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewFileStore(path, staticPassphrase("hunter2")).Load(); err == nil {
+			t.Errorf("%s: loaded a damaged database", name)
+		}
+	}
+}
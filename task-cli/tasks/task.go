@@ -0,0 +1,210 @@
+// Package tasks implements task-cli's task tracking, independently of the
+// command line: tasks, their storage, and a Manager to change them
+package tasks
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+type TaskStatus int
+
+const (
+	STATUS_TODO        TaskStatus = 0
+	STATUS_IN_PROGRESS TaskStatus = 1
+	STATUS_DONE        TaskStatus = 2
+)
+
+// All statuses, in the order a task moves through them
+var Statuses []TaskStatus = []TaskStatus{STATUS_TODO, STATUS_IN_PROGRESS, STATUS_DONE}
+
+func (s TaskStatus) String() string {
+	switch s {
+	case STATUS_TODO:
+		return "To-do"
+	case STATUS_IN_PROGRESS:
+		return "In Progress"
+	case STATUS_DONE:
+		return "Done"
+	default:
+		return "???"
+	}
+}
+
+func ParseStatus(status string) (TaskStatus, error) {
+	switch status {
+	case "todo", "t":
+		return STATUS_TODO, nil
+	case "in-progress", "p":
+		return STATUS_IN_PROGRESS, nil
+	case "done", "d":
+		return STATUS_DONE, nil
+	default:
+		return 0, fmt.Errorf("'%s' is not a valid status (must be todo, in-progress or done)", status)
+	}
+}
+
+type TaskPriority int
+
+const (
+	PRIORITY_NONE   TaskPriority = 0
+	PRIORITY_LOW    TaskPriority = 1
+	PRIORITY_MEDIUM TaskPriority = 2
+	PRIORITY_HIGH   TaskPriority = 3
+)
+
+func (p TaskPriority) String() string {
+	switch p {
+	case PRIORITY_NONE:
+		return "-"
+	case PRIORITY_LOW:
+		return "Low"
+	case PRIORITY_MEDIUM:
+		return "Medium"
+	case PRIORITY_HIGH:
+		return "High"
+	default:
+		return "???"
+	}
+}
+
+func ParsePriority(priority string) (TaskPriority, error) {
+	switch priority {
+	case "none":
+		return PRIORITY_NONE, nil
+	case "low", "l":
+		return PRIORITY_LOW, nil
+	case "medium", "m":
+		return PRIORITY_MEDIUM, nil
+	case "high", "h":
+		return PRIORITY_HIGH, nil
+	default:
+		return 0, fmt.Errorf("'%s' is not a valid priority (must be none, low, medium or high)", priority)
+	}
+}
+
+const (
+	DUE_FORMAT = "2006-01-02"
+)
+
+// Parses a due date, which is taken to be in the local timezone
+func ParseDue(due string) (time.Time, error) {
+	d, err := time.ParseInLocation(DUE_FORMAT, due, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is not a valid due date (must be YYYY-MM-DD)", due)
+	}
+
+	return d, nil
+}
+
+type Task struct {
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+	Description string       `json:"desc"`
+	Id          uint64       `json:"id"`
+	Status      TaskStatus   `json:"status"`
+	Tags        []string     `json:"tags,omitempty"`
+	Project     string       `json:"project,omitempty"`
+	Priority    TaskPriority `json:"priority,omitempty"`
+	Due         *time.Time   `json:"due,omitempty"`
+	Parent      uint64       `json:"parent,omitempty"`
+	Estimate    *Estimate    `json:"estimate,omitempty"`
+	Source      *SourceRef   `json:"source,omitempty"`
+	History     []Change     `json:"history,omitempty"`
+}
+
+const (
+	FIELD_CREATED     = "created"
+	FIELD_STATUS      = "status"
+	FIELD_DESCRIPTION = "description"
+)
+
+// A single entry in a task's history: who changed what, and when
+type Change struct {
+	At    time.Time `json:"at"`
+	Actor string    `json:"actor,omitempty"`
+	Field string    `json:"field"`
+	From  string    `json:"from,omitempty"`
+	To    string    `json:"to,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Field {
+	case FIELD_CREATED:
+		return fmt.Sprintf("created as '%s'", c.To)
+	case FIELD_STATUS:
+		return fmt.Sprintf("status: %s -> %s", c.From, c.To)
+	case FIELD_DESCRIPTION:
+		return fmt.Sprintf("description: '%s' -> '%s'", c.From, c.To)
+	default:
+		return fmt.Sprintf("%s: '%s' -> '%s'", c.Field, c.From, c.To)
+	}
+}
+
+func (t Task) HasTag(tag string) bool {
+	return slices.Contains(t.Tags, tag)
+}
+
+// Optional changes to a task's fields. Nil fields are left untouched, and a
+// zero due date or parent clears it
+type TaskChanges struct {
+	Description *string
+	Project     *string
+	Priority    *TaskPriority
+	Due         *time.Time
+	Parent      *uint64
+	Estimate    *Estimate
+	Source      *SourceRef
+}
+
+func (c TaskChanges) IsEmpty() bool {
+	return c.Description == nil && c.Project == nil && c.Priority == nil &&
+		c.Due == nil && c.Parent == nil && c.Estimate == nil && c.Source == nil
+}
+
+func (c TaskChanges) apply(task *Task) {
+	if c.Description != nil {
+		task.Description = *c.Description
+	}
+
+	if c.Project != nil {
+		task.Project = *c.Project
+	}
+
+	if c.Priority != nil {
+		task.Priority = *c.Priority
+	}
+
+	if c.Due != nil {
+		due := *c.Due
+		if due.IsZero() {
+			task.Due = nil
+		} else {
+			task.Due = &due
+		}
+	}
+
+	if c.Parent != nil {
+		task.Parent = *c.Parent
+	}
+
+	if c.Estimate != nil {
+		if c.Estimate.Amount == 0 {
+			task.Estimate = nil
+		} else {
+			estimate := *c.Estimate
+			task.Estimate = &estimate
+		}
+	}
+
+	if c.Source != nil {
+		task.Source = c.Source
+	}
+}
+
+// A work-in-progress limit for a status
+type WipLimit struct {
+	Max      int  `json:"max"`
+	WarnOnly bool `json:"warnOnly,omitempty"`
+}
@@ -0,0 +1,240 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A set of tasks that can be created in one go, like a checklist
+//
+// Descriptions, tags and projects can refer to variables as ${name}. Their
+// values are given when applying the template, or taken from Vars, which
+// holds defaults (an empty default makes the variable required)
+type Template struct {
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Vars        map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
+	Tasks       []TemplateTask    `json:"tasks" yaml:"tasks"`
+}
+
+// A task in a template. Its children become child tasks of it
+type TemplateTask struct {
+	Description string         `json:"desc" yaml:"desc"`
+	Tags        []string       `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Project     string         `json:"project,omitempty" yaml:"project,omitempty"`
+	Priority    string         `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Children    []TemplateTask `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// Template file extensions, in the order they're looked for
+var TemplateExtensions []string = []string{".yaml", ".yml", ".json"}
+
+// Reads a template from a YAML or JSON file, going by its extension
+//
+// Templates without a name are named after their file
+func LoadTemplate(path string) (*Template, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &Template{}
+
+	ext := filepath.Ext(path)
+	switch ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(file, tmpl)
+	case ".json":
+		err = json.Unmarshal(file, tmpl)
+	default:
+		return nil, fmt.Errorf("'%s' isn't a template (must be .yaml, .yml or .json)", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error reading template '%s': %v", path, err)
+	}
+
+	if tmpl.Name == "" {
+		tmpl.Name = strings.TrimSuffix(filepath.Base(path), ext)
+	}
+
+	return tmpl, nil
+}
+
+// Returns the template's variables, filled in with the given values
+func (t *Template) resolveVars(values map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(t.Vars)+len(values))
+	for k, v := range t.Vars {
+		vars[k] = v
+	}
+
+	for k, v := range values {
+		vars[k] = v
+	}
+
+	missing := []string{}
+	for k, v := range vars {
+		if v == "" {
+			missing = append(missing, k)
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("Missing values for variable(s): %s", strings.Join(missing, ", "))
+	}
+
+	return vars, nil
+}
+
+var varPattern *regexp.Regexp = regexp.MustCompile(`\$\{([\w-]+)\}`)
+
+// Replaces ${name} references, failing on unknown variables. Anything else,
+// like a bare $5, is left as it is
+func expand(text string, vars map[string]string) (string, error) {
+	var unknown string
+
+	expanded := varPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		name := varPattern.FindStringSubmatch(ref)[1]
+
+		v, ok := vars[name]
+		if !ok && unknown == "" {
+			unknown = name
+		}
+
+		return v
+	})
+
+	if unknown != "" {
+		return "", fmt.Errorf("Unknown variable '%s' in '%s'", unknown, text)
+	}
+
+	return expanded, nil
+}
+
+// A template task, ready to be added
+type plannedTask struct {
+	desc     string
+	tags     []string
+	changes  TaskChanges
+	children []plannedTask
+}
+
+func (t TemplateTask) plan(vars map[string]string) (plannedTask, error) {
+	p := plannedTask{}
+
+	desc, err := expand(t.Description, vars)
+	if err != nil {
+		return p, err
+	}
+
+	if desc == "" {
+		return p, ErrEmptyDescription
+	}
+
+	p.desc = desc
+
+	for _, tag := range t.Tags {
+		expanded, err := expand(tag, vars)
+		if err != nil {
+			return p, err
+		}
+
+		p.tags = append(p.tags, expanded)
+	}
+
+	if t.Project != "" {
+		project, err := expand(t.Project, vars)
+		if err != nil {
+			return p, err
+		}
+
+		p.changes.Project = &project
+	}
+
+	if t.Priority != "" {
+		priority, err := ParsePriority(t.Priority)
+		if err != nil {
+			return p, err
+		}
+
+		p.changes.Priority = &priority
+	}
+
+	for _, child := range t.Children {
+		c, err := child.plan(vars)
+		if err != nil {
+			return p, err
+		}
+
+		p.children = append(p.children, c)
+	}
+
+	return p, nil
+}
+
+func (m *Manager) addPlanned(p plannedTask, parent uint64, added []Task) ([]Task, error) {
+	if parent != 0 {
+		p.changes.Parent = &parent
+	}
+
+	task, err := m.Add(p.desc, p.tags, p.changes)
+	if err != nil {
+		return added, err
+	}
+
+	added = append(added, task)
+	for _, child := range p.children {
+		if added, err = m.addPlanned(child, task.Id, added); err != nil {
+			return added, err
+		}
+	}
+
+	return added, nil
+}
+
+// Creates the template's tasks, filling in its variables with values
+//
+// The whole template is checked before anything is added, so a bad variable
+// or priority doesn't leave half a checklist behind. Neither does a hook
+// refusing one of its tasks, since then none of them are added
+func (m *Manager) ApplyTemplate(t *Template, values map[string]string) ([]Task, error) {
+	vars, err := t.resolveVars(values)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := make([]plannedTask, 0, len(t.Tasks))
+	for _, task := range t.Tasks {
+		p, err := task.plan(vars)
+		if err != nil {
+			return nil, err
+		}
+
+		planned = append(planned, p)
+	}
+
+	added := []Task{}
+	err = m.allOrNothing(func() error {
+		for _, p := range planned {
+			if added, err = m.addPlanned(p, 0, added); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
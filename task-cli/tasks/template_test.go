@@ -0,0 +1,187 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const releaseTemplate = `
+description: Release checklist
+vars:
+  version: ""
+  team: infra
+tasks:
+  - desc: Release ${version}
+    tags: ["${team}"]
+    children:
+      - desc: Tag v${version}
+  - desc: Announce ${version}
+`
+
+func writeTemplate(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestApplyTemplate(t *testing.T) {
+	tmpl, err := LoadTemplate(writeTemplate(t, "release.yaml", releaseTemplate))
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+
+	if tmpl.Name != "release" {
+		t.Errorf("got name %q, want it taken from the file name", tmpl.Name)
+	}
+
+	m, _ := newTestManager(t)
+
+	added, err := m.ApplyTemplate(tmpl, map[string]string{"version": "1.4"})
+	if err != nil {
+		t.Fatalf("ApplyTemplate: %v", err)
+	}
+
+	want := []struct {
+		desc   string
+		parent uint64
+	}{
+		{"Release 1.4", 0},
+		{"Tag v1.4", 1},
+		{"Announce 1.4", 0},
+	}
+
+	if len(added) != len(want) {
+		t.Fatalf("got %d tasks, want %d", len(added), len(want))
+	}
+
+	for idx, task := range added {
+		if task.Description != want[idx].desc || task.Parent != want[idx].parent {
+			t.Errorf("task %d: got %q (parent %d), want %q (parent %d)",
+				idx, task.Description, task.Parent, want[idx].desc, want[idx].parent)
+		}
+	}
+
+	if !added[0].HasTag("infra") {
+		t.Errorf("default variable not used in tags: %v", added[0].Tags)
+	}
+}
+
+func TestApplyTemplateIsAllOrNothing(t *testing.T) {
+	tmpl, err := LoadTemplate(writeTemplate(t, "bad.json",
+		`{"tasks": [{"desc": "fine"}, {"desc": "bad", "priority": "urgent"}]}`))
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+
+	m, _ := newTestManager(t)
+
+	if _, err := m.ApplyTemplate(tmpl, nil); err == nil {
+		t.Fatalf("expected an error for an invalid priority")
+	}
+
+	if len(m.IDs()) != 0 {
+		t.Errorf("a failed template left %d tasks behind", len(m.IDs()))
+	}
+}
+
+func TestApplyTemplateHookVeto(t *testing.T) {
+	tmpl, err := LoadTemplate(writeTemplate(t, "release.yaml", releaseTemplate))
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+
+	m, _ := newTestManager(t)
+
+	// The third task is refused, after the first two were added
+	hooks := &fakeHooks{veto: 3}
+	m.SetHooks(hooks)
+
+	if _, err := m.ApplyTemplate(tmpl, map[string]string{"version": "1.4"}); !errors.Is(err, ErrHookVeto) {
+		t.Fatalf("got %v, want ErrHookVeto", err)
+	}
+
+	if len(hooks.calls) != 3 {
+		t.Errorf("got %d hook calls, want 3", len(hooks.calls))
+	}
+
+	if ids := m.IDs(); len(ids) != 0 {
+		t.Errorf("a refused template left tasks %v behind", ids)
+	}
+}
+
+func TestApplyTemplateVariables(t *testing.T) {
+	tmpl, err := LoadTemplate(writeTemplate(t, "release.yaml", releaseTemplate))
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+
+	m, _ := newTestManager(t)
+
+	if _, err := m.ApplyTemplate(tmpl, nil); err == nil {
+		t.Errorf("applied a template without its required variable")
+	}
+
+	tmpl.Tasks = append(tmpl.Tasks, TemplateTask{Description: "${nope}"})
+	if _, err := m.ApplyTemplate(tmpl, map[string]string{"version": "1"}); err == nil {
+		t.Errorf("applied a template with an unknown variable")
+	}
+}
+
+func TestAddParent(t *testing.T) {
+	m, _ := newTestManager(t)
+	mustAdd(t, m, "parent")
+
+	parent := uint64(1)
+	if _, err := m.Add("child", nil, TaskChanges{Parent: &parent}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if children := m.Children(1); len(children) != 1 || children[0].Id != 2 {
+		t.Errorf("got children %v, want task 2", children)
+	}
+
+	missing := uint64(9)
+	if _, err := m.Add("orphan", nil, TaskChanges{Parent: &missing}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+
+	child := uint64(2)
+	if _, err := m.Update([]uint64{1}, TaskChanges{Parent: &child}); !errors.Is(err, ErrParentCycle) {
+		t.Errorf("got %v, want ErrParentCycle", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{"version": "1.4", "release-date": "2026-10-01"}
+
+	tests := map[string]string{
+		"Release ${version}":       "Release 1.4",
+		"Budget $5 for ${version}": "Budget $5 for 1.4",
+		"Ship on ${release-date}":  "Ship on 2026-10-01",
+		"Costs $version, not ${ }": "Costs $version, not ${ }",
+		"${version}${version}":     "1.41.4",
+	}
+
+	for text, want := range tests {
+		got, err := expand(text, vars)
+		if err != nil {
+			t.Errorf("expand(%q): %v", text, err)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("expand(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	if _, err := expand("Release ${nope}", vars); err == nil {
+		t.Errorf("expanded an unknown variable")
+	}
+}